@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cortze/ipfs-cid-hoarder/pkg/config"
+
+	"github.com/pkg/errors"
+	cli "github.com/urfave/cli/v2"
+)
+
+var ConfigCmd = &cli.Command{
+	Name:  "config",
+	Usage: "inspects the configuration of the hoarder",
+	Subcommands: []*cli.Command{
+		{
+			Name:   "check",
+			Usage:  "validates the configuration of a run and prints the resolved effective config as JSON",
+			Action: CheckConfig,
+			Flags:  runFlags(), // same flags as the run command
+		},
+	},
+}
+
+func CheckConfig(ctx *cli.Context) error {
+	// compose the config exactly as the run command would do it
+	conf, err := config.NewConfig(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to generate config from arguments")
+	}
+
+	resolvedConf, err := json.MarshalIndent(conf, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal the resolved config")
+	}
+	fmt.Println(string(resolvedConf))
+
+	if err := conf.Validate(); err != nil {
+		return err
+	}
+	fmt.Println("configuration is valid")
+	return nil
+}
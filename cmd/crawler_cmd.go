@@ -18,6 +18,11 @@ var CrawlerCmd = &cli.Command{
 	Usage:  "starts simple crawl over the IPFS Network",
 	Action: RunCrawler,
 	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "config-file",
+			Usage:   "json/yaml file with the configuration of the crawl (env vars and flags take precedence over it)",
+			EnvVars: []string{"IPFS_CID_HOARDER_CONFIG_FILE"},
+		},
 		&cli.StringFlag{
 			Name:        "log-level",
 			Usage:       "verbosity of the logs that will be displayed [debug,warn,info,error]",
@@ -26,7 +31,8 @@ var CrawlerCmd = &cli.Command{
 			Value:       config.DefaultConfig.LogLevel,
 		},
 		&cli.StringFlag{
-			Name:        "blacklisting-user-agent",
+			Name:        "blacklisted-ua",
+			Aliases:     []string{"blacklisting-user-agent"},
 			Usage:       "user agent that wants to be blacklisted as fruit of the crawl",
 			EnvVars:     []string{"IPFS_CID_HOARDER_BLOCKLIST_USER_AGENT"},
 			DefaultText: config.DefaultBlacklistUserAgent,
@@ -61,14 +67,7 @@ func RunCrawler(ctx *cli.Context) error {
 		}
 	}()
 
-	var blacklistUserAgent string
-
-	// check if a blacklisting user agent has been submitted
-	if ctx.IsSet("blacklisting-user-agent") {
-		blacklistUserAgent = ctx.String("blacklisting-user-agent")
-	}
-
-	_, err = crawler.RunLightCrawler(ctx.Context, blacklistUserAgent)
+	_, err = crawler.RunLightCrawler(ctx.Context, conf.BlacklistedUA)
 	if err != nil {
 		return err
 	}
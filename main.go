@@ -35,6 +35,7 @@ func main() {
 		Commands: []*cli.Command{
 			cmd.RunCmd,
 			cmd.CrawlerCmd,
+			cmd.ConfigCmd,
 		},
 	}
 
@@ -1,6 +1,12 @@
 package config
 
 import (
+	"encoding/json"
+	"time"
+
+	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
+
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 )
@@ -14,61 +20,160 @@ var MetricsIp = "127.0.0.1"
 var MetricsPort = "9022"
 var DefaultBlacklistUserAgent = ""
 var DefaultDHTProvideOperation = "standard"
+var DefaultCidSource = "random-content-gen"
+var DefaultCidPrefix = models.DefaultCidPrefix
 
 // default configuration
 var DefaultConfig = Config{
-	Port:             "9010",
-	MetricsIP:        MetricsIp,
-	MetricsPort:      MetricsPort,
-	LogLevel:         "info",
-	Database:         "postgres://user:password@ip:port/db",
-	CidContentSize:   1024, // 1MB in KBs
-	CidNumber:        10,
-	Publishers:       1,
-	Pingers:          250,
-	Hosts:            10,
-	PubInterval:      "80s",
-	TaskTimeout:      "80s",
-	ReqInterval:      "30m",
-	CidPingTime:      "48h",
-	K:                20,
-	ProvideOperation: DefaultDHTProvideOperation,
-	BlacklistedUA:    DefaultBlacklistUserAgent,
+	Port:                 "9010",
+	MetricsIP:            MetricsIp,
+	MetricsPort:          MetricsPort,
+	LogLevel:             "info",
+	Database:             "postgres://user:password@ip:port/db",
+	CidSource:            DefaultCidSource,
+	CidFile:              "",
+	AlreadyPublishedCids: false,
+	ImportPath:           "",
+	Chunker:              "size-262144",
+	DagLayout:            "balanced",
+	ProvideStrategy:      "root",
+	CidPrefixes:          []string{DefaultCidPrefix},
+	Seed:                 0,
+	HashTarget:           "",
+	HashTargetBudget:     Duration{30 * time.Second},
+	CidContentSize:       1024, // 1MB in KBs
+	ContentSizeDist:      "",
+	CidNumber:            10,
+	Publishers:           1,
+	PublisherHosts:       1,
+	ProvidersPerCid:      1,
+	ProviderStagger:      Duration{0},
+	Pingers:              250,
+	Hosts:                10,
+	PubInterval:          Duration{80 * time.Second},
+	PubSchedule:          "",
+	TaskTimeout:          Duration{80 * time.Second},
+	ReqInterval:          Duration{30 * time.Minute},
+	PingSchedule:         "",
+	CidPingTime:          Duration{48 * time.Hour},
+	RepublishInterval:    Duration{0},
+	CreatorOffline:       "",
+	IpnsRecords:          false,
+	IpnsValidity:         Duration{48 * time.Hour},
+	DelegatedRouting:     "",
+	ProviderLookupTarget: 0,
+	K:                    20,
+	ProvideOperation:     DefaultDHTProvideOperation,
+	ProvideBatchSize:     1,
+	ProvideRetries:       0,
+	ProvideRetryBackoff:  Duration{1 * time.Minute},
+	MinPRHolders:         0,
+	UnderReplicated:      "degrade",
+	BlacklistedUA:        DefaultBlacklistUserAgent,
 }
 
 // Config compiles all the set of flags that can be read by the user while launching the cli
 type Config struct {
-	Port             string `json:"port"`
-	MetricsIP        string `json:"metrics-ip"`
-	MetricsPort      string `json:"metrics-port"`
-	LogLevel         string `json:"log-level"`
-	Database         string `json:"database-endpoint"`
-	CidContentSize   int    `json:"cid-content-size"`
-	CidNumber        int    `json:"cid-number"`
-	Publishers       int    `json:"publishers"`
-	Pingers          int    `json:"pingers"`
-	Hosts            int    `json:"hosts"`
-	PubInterval      string `json:"pub-interval"`
-	TaskTimeout      string `json:"task-timeout"`
-	ReqInterval      string `json:"req-interval"`
-	CidPingTime      string `json:"cid-ping-time"`
-	K                int    `json:"k"`
-	ProvideOperation string `json:"prov-op"`
-	BlacklistedUA    string `json:"blacklisted-ua"`
+	Port                 string   `json:"port"`
+	MetricsIP            string   `json:"metrics-ip"`
+	MetricsPort          string   `json:"metrics-port"`
+	LogLevel             string   `json:"log-level"`
+	Database             string   `json:"database-endpoint"`
+	CidSource            string   `json:"cid-source"`
+	CidFile              string   `json:"cid-file"`
+	AlreadyPublishedCids bool     `json:"already-published-cids"`
+	ImportPath           string   `json:"import-path"`
+	Chunker              string   `json:"chunker"`
+	DagLayout            string   `json:"dag-layout"`
+	ProvideStrategy      string   `json:"provide-strategy"`
+	CidPrefixes          []string `json:"cid-prefixes"`
+	Seed                 int64    `json:"seed"`
+	HashTarget           string   `json:"hash-target"`
+	HashTargetBudget     Duration `json:"hash-target-budget"`
+	CidContentSize       int      `json:"cid-content-size"`
+	ContentSizeDist      string   `json:"content-size-dist"`
+	CidNumber            int      `json:"cid-number"`
+	Publishers           int      `json:"publishers"`
+	PublisherHosts       int      `json:"publisher-hosts"`
+	ProvidersPerCid      int      `json:"providers-per-cid"`
+	ProviderStagger      Duration `json:"provider-stagger"`
+	Pingers              int      `json:"pingers"`
+	Hosts                int      `json:"hosts"`
+	PubInterval          Duration `json:"pub-interval"`
+	PubSchedule          string   `json:"pub-schedule"`
+	TaskTimeout          Duration `json:"task-timeout"`
+	ReqInterval          Duration `json:"req-interval"`
+	PingSchedule         string   `json:"ping-schedule"`
+	CidPingTime          Duration `json:"cid-ping-time"`
+	RepublishInterval    Duration `json:"republish-interval"`
+	CreatorOffline       string   `json:"creator-offline-windows"`
+	IpnsRecords          bool     `json:"ipns-records"`
+	IpnsValidity         Duration `json:"ipns-validity"`
+	DelegatedRouting     string   `json:"delegated-routing"`
+	ProviderLookupTarget int      `json:"provider-lookup-target"`
+	K                    int      `json:"k"`
+	ProvideOperation     string   `json:"prov-op"`
+	ProvideBatchSize     int      `json:"provide-batch-size"`
+	ProvideRetries       int      `json:"provide-retries"`
+	ProvideRetryBackoff  Duration `json:"provide-retry-backoff"`
+	MinPRHolders         int      `json:"min-pr-holders"`
+	UnderReplicated      string   `json:"under-replicated-policy"`
+	BlacklistedUA        string   `json:"blacklisted-ua"`
+}
+
+// Duration wraps time.Duration so that it can be read and written as a human readable string ("80s", "30m")
+// in the config files and in the resolved config
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var str string
+	if err := json.Unmarshal(b, &str); err != nil {
+		return errors.Wrap(err, "durations must be strings (example '80s' - '30m' - '48h')")
+	}
+	dur, err := time.ParseDuration(str)
+	if err != nil {
+		return err
+	}
+	d.Duration = dur
+	return nil
 }
 
-// Init takes the command line argumenst from the urfave/cli context and composes the configuration
+// NewConfig takes the command line argumenst from the urfave/cli context and composes the configuration.
+// The configuration is layered: defaults, then the values of the config file (if any),
+// and finally the env vars and explicit flags (urfave/cli already gives priority to the flags over the env vars)
 func NewConfig(ctx *cli.Context) (*Config, error) {
-	c := &Config{}
+	c := DefaultConfig
+	if ctx.IsSet("config-file") {
+		configFile := ctx.String("config-file")
+		log.Infof("reading configuration from file %s", configFile)
+		if err := c.ApplyFile(configFile); err != nil {
+			return nil, err
+		}
+	}
 	c.Apply(ctx)
-	return c, nil
-
+	return &c, nil
 }
 
 // apply parses the arguments readed from cli.Context
 func (c *Config) Apply(ctx *cli.Context) {
 	// Check if the flags have been set
-	if ctx.Command.Name == "run" {
+	switch ctx.Command.Name {
+	case "crawler":
+		if ctx.IsSet("log-level") {
+			c.LogLevel = ctx.String("log-level")
+		}
+
+		if ctx.IsSet("blacklisted-ua") {
+			c.BlacklistedUA = ctx.String("blacklisted-ua")
+		}
+
+	case "run", "check":
 		if ctx.IsSet("port") {
 			c.Port = ctx.String("port")
 		}
@@ -89,10 +194,58 @@ func (c *Config) Apply(ctx *cli.Context) {
 			c.Database = ctx.String("database-endpoint")
 		}
 
+		if ctx.IsSet("cid-source") {
+			c.CidSource = ctx.String("cid-source")
+		}
+
+		if ctx.IsSet("cid-file") {
+			c.CidFile = ctx.String("cid-file")
+		}
+
+		if ctx.IsSet("already-published-cids") {
+			c.AlreadyPublishedCids = ctx.Bool("already-published-cids")
+		}
+
+		if ctx.IsSet("import-path") {
+			c.ImportPath = ctx.String("import-path")
+		}
+
+		if ctx.IsSet("chunker") {
+			c.Chunker = ctx.String("chunker")
+		}
+
+		if ctx.IsSet("dag-layout") {
+			c.DagLayout = ctx.String("dag-layout")
+		}
+
+		if ctx.IsSet("provide-strategy") {
+			c.ProvideStrategy = ctx.String("provide-strategy")
+		}
+
+		if ctx.IsSet("cid-prefix") {
+			c.CidPrefixes = ctx.StringSlice("cid-prefix")
+		}
+
+		if ctx.IsSet("seed") {
+			c.Seed = ctx.Int64("seed")
+		}
+
+		if ctx.IsSet("hash-target") {
+			c.HashTarget = ctx.String("hash-target")
+		}
+
+		if ctx.IsSet("hash-target-budget") {
+			c.HashTargetBudget = Duration{ctx.Duration("hash-target-budget")}
+		}
+
 		if ctx.IsSet("cid-content-size") {
 			c.CidContentSize = ctx.Int("cid-content-size")
 		}
 
+		if ctx.IsSet("content-size-dist") {
+			c.ContentSizeDist = ctx.String("content-size-dist")
+		}
+
 		if ctx.IsSet("cid-number") {
 			c.CidNumber = ctx.Int("cid-number")
 		}
@@ -101,6 +254,18 @@ func (c *Config) Apply(ctx *cli.Context) {
 			c.Publishers = ctx.Int("publishers")
 		}
 
+		if ctx.IsSet("publisher-hosts") {
+			c.PublisherHosts = ctx.Int("publisher-hosts")
+		}
+
+		if ctx.IsSet("providers-per-cid") {
+			c.ProvidersPerCid = ctx.Int("providers-per-cid")
+		}
+
+		if ctx.IsSet("provider-stagger") {
+			c.ProviderStagger = Duration{ctx.Duration("provider-stagger")}
+		}
+
 		if ctx.IsSet("pingers") {
 			c.Pingers = ctx.Int("pingers")
 		}
@@ -110,19 +275,51 @@ func (c *Config) Apply(ctx *cli.Context) {
 		}
 
 		if ctx.IsSet("pub-interval") {
-			c.PubInterval = ctx.String("pub-interval")
+			c.PubInterval = Duration{ctx.Duration("pub-interval")}
+		}
+
+		if ctx.IsSet("pub-schedule") {
+			c.PubSchedule = ctx.String("pub-schedule")
 		}
 
 		if ctx.IsSet("task-timeout") {
-			c.TaskTimeout = ctx.String("task-timeout")
+			c.TaskTimeout = Duration{ctx.Duration("task-timeout")}
 		}
 
 		if ctx.IsSet("req-interval") {
-			c.ReqInterval = ctx.String("req-interval")
+			c.ReqInterval = Duration{ctx.Duration("req-interval")}
+		}
+
+		if ctx.IsSet("ping-schedule") {
+			c.PingSchedule = ctx.String("ping-schedule")
 		}
 
 		if ctx.IsSet("cid-ping-time") {
-			c.CidPingTime = ctx.String("cid-ping-time")
+			c.CidPingTime = Duration{ctx.Duration("cid-ping-time")}
+		}
+
+		if ctx.IsSet("republish-interval") {
+			c.RepublishInterval = Duration{ctx.Duration("republish-interval")}
+		}
+
+		if ctx.IsSet("creator-offline-windows") {
+			c.CreatorOffline = ctx.String("creator-offline-windows")
+		}
+
+		if ctx.IsSet("ipns-records") {
+			c.IpnsRecords = ctx.Bool("ipns-records")
+		}
+
+		if ctx.IsSet("ipns-validity") {
+			c.IpnsValidity = Duration{ctx.Duration("ipns-validity")}
+		}
+
+		if ctx.IsSet("delegated-routing") {
+			c.DelegatedRouting = ctx.String("delegated-routing")
+		}
+
+		if ctx.IsSet("provider-lookup-target") {
+			c.ProviderLookupTarget = ctx.Int("provider-lookup-target")
 		}
 
 		if ctx.IsSet("k") {
@@ -133,6 +330,26 @@ func (c *Config) Apply(ctx *cli.Context) {
 			c.ProvideOperation = ctx.String("prov-op")
 		}
 
+		if ctx.IsSet("provide-batch-size") {
+			c.ProvideBatchSize = ctx.Int("provide-batch-size")
+		}
+
+		if ctx.IsSet("provide-retries") {
+			c.ProvideRetries = ctx.Int("provide-retries")
+		}
+
+		if ctx.IsSet("provide-retry-backoff") {
+			c.ProvideRetryBackoff = Duration{ctx.Duration("provide-retry-backoff")}
+		}
+
+		if ctx.IsSet("min-pr-holders") {
+			c.MinPRHolders = ctx.Int("min-pr-holders")
+		}
+
+		if ctx.IsSet("under-replicated-policy") {
+			c.UnderReplicated = ctx.String("under-replicated-policy")
+		}
+
 		if ctx.IsSet("blacklisted-ua") {
 			c.BlacklistedUA = ctx.String("blacklisted-ua")
 		}
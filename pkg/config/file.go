@@ -0,0 +1,55 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// ApplyFile reads the configuration of a study from a JSON or YAML file and overrides the fields of the Config
+// with the ones defined in the file. The keys of the file are the same as the cli flags (json tags of the Config),
+// and any key that doesn't belong to the Config is considered an error.
+func (c *Config) ApplyFile(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, "reading config file "+path)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		// nothing to do, the content is already in JSON
+	case ".yaml", ".yml":
+		// translate the YAML into JSON, so that the json tags remain the only reference of the keys
+		raw, err = yamlToJSON(raw)
+		if err != nil {
+			return errors.Wrap(err, "parsing yaml config file "+path)
+		}
+	default:
+		return errors.New("unsupported config file extension " + filepath.Ext(path) + " (expected .json, .yaml or .yml)")
+	}
+
+	// decode over the current Config, so that only the keys present in the file get overridden
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return errors.Wrap(err, "decoding config file "+path)
+	}
+	return nil
+}
+
+// yamlToJSON converts a YAML document into its JSON representation
+func yamlToJSON(raw []byte) ([]byte, error) {
+	var content map[string]interface{}
+	if err := yaml.Unmarshal(raw, &content); err != nil {
+		return nil, err
+	}
+	if content == nil {
+		content = make(map[string]interface{})
+	}
+	return json.Marshal(content)
+}
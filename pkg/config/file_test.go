@@ -0,0 +1,77 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestApplyFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		fails   bool
+	}{
+		{
+			name:    "json",
+			file:    "conf.json",
+			content: `{"cid-number": 50, "req-interval": "10m", "prov-op": "optimistic"}`,
+		},
+		{
+			name:    "yaml",
+			file:    "conf.yaml",
+			content: "cid-number: 50\nreq-interval: 10m\nprov-op: optimistic\n",
+		},
+		{
+			name:    "unknown json key",
+			file:    "conf.json",
+			content: `{"cid-number": 50, "cid-numbr": 10}`,
+			fails:   true,
+		},
+		{
+			name:    "unknown yaml key",
+			file:    "conf.yml",
+			content: "cid-number: 50\ncid-numbr: 10\n",
+			fails:   true,
+		},
+		{
+			name:    "unsupported extension",
+			file:    "conf.toml",
+			content: `cid-number = 50`,
+			fails:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conf := DefaultConfig
+			err := conf.ApplyFile(writeConfigFile(t, test.file, test.content))
+			if test.fails {
+				if err == nil {
+					t.Fatal("expected error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if conf.CidNumber != 50 || conf.ReqInterval.Duration != 10*time.Minute || conf.ProvideOperation != "optimistic" {
+				t.Fatalf("file values not applied: %+v", conf)
+			}
+			// keys missing in the file keep their previous value
+			if conf.Pingers != DefaultConfig.Pingers {
+				t.Fatalf("pingers overridden: got %d, expected %d", conf.Pingers, DefaultConfig.Pingers)
+			}
+		})
+	}
+}
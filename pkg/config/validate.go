@@ -0,0 +1,302 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
+
+	chunker "github.com/ipfs/boxo/chunker"
+)
+
+// minimum publication interval, the publisher gives each provide PubInterval-1s to finish
+const minPubInterval = 1 * time.Second
+
+var (
+	SupportedLogLevels         = []string{"trace", "debug", "info", "warn", "error"}
+	SupportedProvideOperations = []string{"standard", "optimistic", "fullrt"}
+	SupportedCidSources        = []string{"random-content-gen", "text-file", "json-file", "car-file", "unixfs-import"}
+	SupportedDagLayouts        = []string{"balanced", "trickle"}
+	SupportedProvideStrategies = []string{"root", "file-roots", "all"}
+	SupportedUnderReplicated   = []string{"degrade", "discard"}
+
+	// sources that read the CIDs from the cid-file
+	fileCidSources = []string{"text-file", "json-file", "car-file"}
+)
+
+// ValidationError gathers all the problems found while validating a Config
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration (%d problems):\n - %s", len(e.Problems), strings.Join(e.Problems, "\n - "))
+}
+
+func (e *ValidationError) add(format string, args ...interface{}) {
+	e.Problems = append(e.Problems, fmt.Sprintf(format, args...))
+}
+
+// Validate checks the Config looking for malformed values or impossible combinations of them,
+// returning a ValidationError with every problem found (nil if the Config is valid)
+func (c *Config) Validate() error {
+	verr := &ValidationError{Problems: make([]string, 0)}
+
+	// network and logging
+	if !isValidPort(c.Port) {
+		verr.add("port %q is not a valid port number", c.Port)
+	}
+	if !isValidPort(c.MetricsPort) {
+		verr.add("metrics-port %q is not a valid port number", c.MetricsPort)
+	}
+	if !contains(SupportedLogLevels, c.LogLevel) {
+		verr.add("log-level %q is not supported %v", c.LogLevel, SupportedLogLevels)
+	}
+	if c.Database == "" || c.Database == DefaultConfig.Database {
+		verr.add("no database-endpoint was provided (flag, env var or config file)")
+	}
+
+	// cid generation and publication
+	if !contains(SupportedCidSources, c.CidSource) {
+		verr.add("cid-source %q is not supported %v", c.CidSource, SupportedCidSources)
+	} else if contains(fileCidSources, c.CidSource) {
+		if c.CidFile == "" {
+			verr.add("cid-source %q requires a cid-file", c.CidSource)
+		} else if _, err := os.Stat(c.CidFile); err != nil {
+			verr.add("cid-file %q can't be read: %s", c.CidFile, err)
+		}
+	} else if c.CidSource == "unixfs-import" {
+		if c.ImportPath == "" {
+			verr.add("cid-source %q requires an import-path", c.CidSource)
+		} else if _, err := os.Stat(c.ImportPath); err != nil {
+			verr.add("import-path %q can't be read: %s", c.ImportPath, err)
+		}
+		if _, err := chunker.FromString(strings.NewReader(""), c.Chunker); err != nil {
+			verr.add("chunker %q is not valid: %s", c.Chunker, err)
+		}
+		if !contains(SupportedDagLayouts, c.DagLayout) {
+			verr.add("dag-layout %q is not supported %v", c.DagLayout, SupportedDagLayouts)
+		}
+		if !contains(SupportedProvideStrategies, c.ProvideStrategy) {
+			verr.add("provide-strategy %q is not supported %v", c.ProvideStrategy, SupportedProvideStrategies)
+		}
+	}
+	if c.AlreadyPublishedCids && !contains(fileCidSources, c.CidSource) {
+		verr.add("already-published-cids requires the CIDs to come from a file %v, not from %s", fileCidSources, c.CidSource)
+	}
+	if _, err := models.ParseCidPrefixes(c.CidPrefixes); err != nil {
+		verr.add("cid-prefix: %s", err)
+	}
+	if target, err := models.ParseHashTarget(c.HashTarget); err != nil {
+		verr.add("hash-target: %s", err)
+	} else if target != nil {
+		if c.CidSource != DefaultCidSource {
+			verr.add("hash-target can only be used with %s cids, not with %s", DefaultCidSource, c.CidSource)
+		}
+		if c.HashTargetBudget.Duration <= 0 {
+			verr.add("hash-target-budget has to be longer than 0s (got %s)", c.HashTargetBudget)
+		}
+	}
+	if c.CidContentSize <= 0 {
+		verr.add("cid-content-size has to be bigger than 0 (got %d)", c.CidContentSize)
+	} else if _, err := models.ParseContentSizeDist(c.ContentSizeDist, c.CidContentSize); err != nil {
+		verr.add("content-size-dist: %s", err)
+	} else if c.ContentSizeDist != "" && c.CidSource != DefaultCidSource {
+		verr.add("content-size-dist can only be used with %s cids, not with %s", DefaultCidSource, c.CidSource)
+	}
+	if c.CidNumber == 0 || c.CidNumber < -1 {
+		verr.add("cid-number has to be bigger than 0, or -1 for a continuous measurement (got %d)", c.CidNumber)
+	}
+	if c.Publishers <= 0 {
+		verr.add("publishers has to be bigger than 0 (got %d)", c.Publishers)
+	}
+	if c.PublisherHosts <= 0 {
+		verr.add("publisher-hosts has to be bigger than 0 (got %d)", c.PublisherHosts)
+	} else if c.PublisherHosts > 1 && c.AlreadyPublishedCids {
+		verr.add("publisher-hosts can't be used with already-published-cids, the discoverer doesn't publish them")
+	}
+	if c.ProvidersPerCid <= 0 {
+		verr.add("providers-per-cid has to be bigger than 0 (got %d)", c.ProvidersPerCid)
+	} else if c.ProvidersPerCid > c.PublisherHosts {
+		verr.add("providers-per-cid (%d) can't be bigger than publisher-hosts (%d)", c.ProvidersPerCid, c.PublisherHosts)
+	}
+	if c.ProviderStagger.Duration < 0 {
+		verr.add("provider-stagger can't be negative (got %s)", c.ProviderStagger)
+	}
+	if c.PubInterval.Duration <= minPubInterval {
+		verr.add("pub-interval has to be longer than %s (got %s)", minPubInterval, c.PubInterval)
+	}
+	if c.PubSchedule != "" {
+		if _, err := models.ParsePubSchedule(c.PubSchedule, 0); err != nil {
+			verr.add("pub-schedule: %s", err)
+		} else if c.AlreadyPublishedCids {
+			verr.add("pub-schedule can't be used with already-published-cids, the discoverer doesn't publish them")
+		}
+	}
+	if c.PingSchedule != "" {
+		if _, err := models.ParsePingSchedule(c.PingSchedule, c.ReqInterval.Duration); err != nil {
+			verr.add("ping-schedule: %s", err)
+		}
+	}
+	if c.RepublishInterval.Duration < 0 {
+		verr.add("republish-interval can't be negative (got %s)", c.RepublishInterval)
+	} else if c.RepublishInterval.Duration > 0 {
+		if c.AlreadyPublishedCids {
+			verr.add("republish-interval can't be used with already-published-cids, the discoverer doesn't publish them")
+		}
+		if c.RepublishInterval.Duration < c.PubInterval.Duration {
+			verr.add("republish-interval (%s) can't be shorter than pub-interval (%s)", c.RepublishInterval, c.PubInterval)
+		}
+		if c.RepublishInterval.Duration >= c.CidPingTime.Duration {
+			verr.add("republish-interval (%s) has to be shorter than cid-ping-time (%s)", c.RepublishInterval, c.CidPingTime)
+		}
+	}
+	if c.CreatorOffline != "" {
+		if windows, err := models.ParseOfflineWindows(c.CreatorOffline); err != nil {
+			verr.add("creator-offline-windows: %s", err)
+		} else if c.AlreadyPublishedCids {
+			verr.add("creator-offline-windows can't be used with already-published-cids, the creators aren't our hosts")
+		} else if len(windows) > 0 && c.CidNumber == -1 {
+			verr.add("creator-offline-windows can't be used with a continuous measurement, the publication never ends")
+		} else if pubPhase, ok := c.publicationPhase(); len(windows) > 0 && ok && windows[0].Start < pubPhase {
+			// an offline creator would make the provides of the publication phase fail
+			verr.add("creator-offline-windows %s-%s starts before the publication phase ends (estimated %s)",
+				windows[0].Start, windows[0].End, pubPhase)
+		}
+	}
+	if c.IpnsRecords {
+		if c.IpnsValidity.Duration <= 0 {
+			verr.add("ipns-validity has to be bigger than 0 (got %s)", c.IpnsValidity)
+		}
+		if c.AlreadyPublishedCids {
+			verr.add("ipns-records can't be used with already-published-cids, the discoverer doesn't publish them")
+		}
+	}
+	if c.DelegatedRouting != "" {
+		if u, err := url.Parse(c.DelegatedRouting); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			verr.add("delegated-routing %q is not a valid http(s) endpoint", c.DelegatedRouting)
+		}
+	}
+	if c.ProviderLookupTarget < 0 {
+		verr.add("provider-lookup-target can't be negative (got %d)", c.ProviderLookupTarget)
+	}
+	if c.K <= 0 {
+		verr.add("k has to be bigger than 0 (got %d)", c.K)
+	}
+	if !contains(SupportedProvideOperations, c.ProvideOperation) {
+		verr.add("prov-op %q is not supported %v", c.ProvideOperation, SupportedProvideOperations)
+	}
+	if c.ProvideOperation == "fullrt" && c.AlreadyPublishedCids {
+		verr.add("prov-op fullrt can't be used with already-published-cids, the discoverer doesn't publish them")
+	}
+	if c.ProvideOperation == "fullrt" && c.PublisherHosts > 1 {
+		// each accelerated client only keeps the peers crawled by its own host, so they can't share the crawl
+		verr.add("prov-op fullrt can only be used with a single publisher host, to crawl the network once (got %d)", c.PublisherHosts)
+	}
+	if c.ProvideBatchSize <= 0 {
+		verr.add("provide-batch-size has to be bigger than 0 (got %d)", c.ProvideBatchSize)
+	} else if c.ProvideBatchSize > 1 && c.ProvideOperation != "fullrt" {
+		verr.add("provide-batch-size can only be used with the fullrt prov-op, not with %s", c.ProvideOperation)
+	}
+
+	if c.ProvideRetries < 0 {
+		verr.add("provide-retries can't be negative (got %d)", c.ProvideRetries)
+	}
+	if c.ProvideRetries > 0 && c.ProvideRetryBackoff.Duration <= 0 {
+		verr.add("provide-retry-backoff has to be longer than 0s (got %s)", c.ProvideRetryBackoff)
+	}
+	if c.MinPRHolders < 0 || c.MinPRHolders > c.K {
+		verr.add("min-pr-holders has to be between 0 and k=%d (got %d)", c.K, c.MinPRHolders)
+	}
+	if !contains(SupportedUnderReplicated, c.UnderReplicated) {
+		verr.add("under-replicated-policy %q is not supported %v", c.UnderReplicated, SupportedUnderReplicated)
+	}
+
+	// cid pinging
+	if c.Pingers <= 0 {
+		verr.add("pingers has to be bigger than 0 (got %d)", c.Pingers)
+	}
+	if c.Hosts <= 0 {
+		verr.add("hosts has to be bigger than 0 (got %d)", c.Hosts)
+	}
+	if c.TaskTimeout.Duration <= 0 {
+		verr.add("task-timeout has to be longer than 0s (got %s)", c.TaskTimeout)
+	}
+	if c.ReqInterval.Duration <= 0 {
+		verr.add("req-interval has to be longer than 0s (got %s)", c.ReqInterval)
+	}
+	if c.TaskTimeout.Duration > c.ReqInterval.Duration {
+		verr.add("task-timeout (%s) can't be longer than req-interval (%s)", c.TaskTimeout, c.ReqInterval)
+	}
+	if c.CidPingTime.Duration < c.ReqInterval.Duration {
+		verr.add("cid-ping-time (%s) can't be shorter than req-interval (%s)", c.CidPingTime, c.ReqInterval)
+	}
+
+	if len(verr.Problems) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// publicationPhase estimates how long it takes to publish all the CIDs since the start of the study,
+// following the publication rate and counting the stagger of the providers and the retries of the last CID.
+// It returns false if the phase can't be estimated (continuous measurement or malformed schedule)
+func (c *Config) publicationPhase() (time.Duration, bool) {
+	if c.CidNumber <= 0 || c.Publishers <= 0 || c.PubInterval.Duration <= 0 {
+		return 0, false
+	}
+	sched, err := models.ParsePubSchedule(c.PubSchedule, float64(c.Publishers)/c.PubInterval.Seconds())
+	if err != nil {
+		return 0, false
+	}
+	var rate float64 // provide slots per second
+	switch sched.Mode {
+	case models.BurstPubSchedule:
+		rate = float64(sched.Burst) / sched.Period.Seconds()
+	case models.DailyPubSchedule:
+		// average rate along the day
+		for _, w := range sched.Windows {
+			length := w.End - w.Start
+			if length < 0 {
+				length += 24 * time.Hour
+			}
+			rate += w.Rate * length.Hours() / 24
+		}
+	default:
+		rate = sched.Rate
+	}
+	if rate <= 0 {
+		return 0, false
+	}
+	batchSize := c.ProvideBatchSize
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	slots := (c.CidNumber + batchSize - 1) / batchSize
+	pubPhase := time.Duration(float64(slots)/rate*float64(time.Second)) + c.PubInterval.Duration
+	if c.ProvidersPerCid > 1 {
+		pubPhase += time.Duration(c.ProvidersPerCid-1) * c.ProviderStagger.Duration
+	}
+	for retry := 0; retry < c.ProvideRetries; retry++ {
+		pubPhase += c.ProvideRetryBackoff.Duration<<retry + c.PubInterval.Duration
+	}
+	return pubPhase, true
+}
+
+func isValidPort(port string) bool {
+	p, err := strconv.Atoi(port)
+	return err == nil && p > 0 && p <= 65535
+}
+
+func contains(list []string, item string) bool {
+	for _, i := range list {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
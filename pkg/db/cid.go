@@ -15,18 +15,37 @@ func (db *DBClient) CreateCidInfoTable() error {
 		CREATE TABLE IF NOT EXISTS cid_info(
 			id SERIAL, 
 			cid_hash TEXT NOT NULL PRIMARY KEY,
+			cid TEXT NOT NULL,
+			cid_prefix TEXT NOT NULL,
 			pub_time TIMESTAMP NOT NULL,
 			provide_time_ms FLOAT NOT NULL,
 			req_interval_m INT NOT NULL,
 			k INT NOT NULL,
 			prov_op TEXT NOT NULL,
-			creator TEXT NOT NULL
+			source TEXT NOT NULL,
+			target_region TEXT NOT NULL,
+			dag_root TEXT NOT NULL,
+			content_size INT NOT NULL,
+			gen_id INT NOT NULL,
+			gen_index INT NOT NULL,
+			creator TEXT NOT NULL,
+			creators TEXT[] NOT NULL,
+			creator_host INT NOT NULL,
+			provide_status TEXT NOT NULL,
+			provide_attempts INT NOT NULL,
+
+			FOREIGN KEY(gen_id) REFERENCES cid_generation(id)
 		);
 				
 		CREATE INDEX IF NOT EXISTS idx_cid_info_cid_hash			ON cid_info (cid_hash);
 		CREATE INDEX IF NOT EXISTS idx_cid_info_pub_time			ON cid_info (pub_time);
 		CREATE INDEX IF NOT EXISTS idx_cid_info_provide_time_ms		ON cid_info (provide_time_ms);
 		CREATE INDEX IF NOT EXISTS idx_cid_info_prov_op				ON cid_info (prov_op);
+		CREATE INDEX IF NOT EXISTS idx_cid_info_cid_prefix			ON cid_info (cid_prefix);
+		CREATE INDEX IF NOT EXISTS idx_cid_info_source				ON cid_info (source);
+		CREATE INDEX IF NOT EXISTS idx_cid_info_dag_root			ON cid_info (dag_root);
+		CREATE INDEX IF NOT EXISTS idx_cid_info_provide_status		ON cid_info (provide_status);
+		CREATE INDEX IF NOT EXISTS idx_cid_info_creator				ON cid_info (creator);
 	`)
 	if err != nil {
 		return errors.Wrap(err, "error preparing statement for CidInfo table generation")
@@ -39,21 +58,54 @@ func (db *DBClient) addCidInfo(cidInfo *models.CidInfo) persistable {
 	persis := newPersistable()
 	persis.query = `INSERT INTO cid_info(
 		cid_hash,
+		cid,
+		cid_prefix,
 		pub_time,
 		provide_time_ms,
 		req_interval_m,
 		k,
 		prov_op,
-		creator) 
-	VALUES($1, $2, $3, $4, $5, $6, $7)`
+		source,
+		target_region,
+		dag_root,
+		content_size,
+		gen_id,
+		gen_index,
+		creator,
+		creators,
+		creator_host,
+		provide_status,
+		provide_attempts) 
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)`
 
 	persis.values = append(persis.values, cidInfo.CID.Hash().B58String())
+	persis.values = append(persis.values, cidInfo.CID.String())
+	persis.values = append(persis.values, models.FormatCidPrefix(cidInfo.CID.Prefix()))
 	persis.values = append(persis.values, cidInfo.PublishTime)
 	persis.values = append(persis.values, cidInfo.ProvideTime.Milliseconds())
 	persis.values = append(persis.values, int(cidInfo.ReqInterval.Minutes()))
 	persis.values = append(persis.values, cidInfo.K)
 	persis.values = append(persis.values, cidInfo.ProvideOp)
+	persis.values = append(persis.values, cidInfo.Source)
+	persis.values = append(persis.values, cidInfo.TargetRegion)
+	// same format as the cid_hash, so that the blocks can be joined with their root
+	dagRoot := ""
+	if cidInfo.DagRoot.Defined() {
+		dagRoot = cidInfo.DagRoot.Hash().B58String()
+	}
+	persis.values = append(persis.values, dagRoot)
+	persis.values = append(persis.values, cidInfo.ContentSize)
+	persis.values = append(persis.values, cidInfo.GenerationID)
+	persis.values = append(persis.values, cidInfo.GenerationIndex)
 	persis.values = append(persis.values, cidInfo.Creator.String())
+	creators := make([]string, 0, len(cidInfo.Creators))
+	for _, creator := range cidInfo.Creators {
+		creators = append(creators, creator.String())
+	}
+	persis.values = append(persis.values, creators)
+	persis.values = append(persis.values, cidInfo.CreatorHost)
+	persis.values = append(persis.values, cidInfo.ProvideStatus)
+	persis.values = append(persis.values, cidInfo.ProvideAttempts)
 
 	return persis
 }
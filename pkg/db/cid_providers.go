@@ -0,0 +1,83 @@
+package db
+
+import (
+	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+func (db *DBClient) CreateCidProvidersTable() error {
+	log.Debugf("creating table 'cid_providers' for DB")
+	_, err := db.psqlPool.Exec(db.ctx, `
+		CREATE TABLE IF NOT EXISTS cid_providers(
+			id SERIAL PRIMARY KEY,
+			cid_hash TEXT NOT NULL,
+			provider_idx INT NOT NULL,
+			provider_id TEXT NOT NULL,
+			host_id INT NOT NULL,
+			provide_delay_m FLOAT NOT NULL,
+			provide_time TIMESTAMP NOT NULL,
+			provide_duration_ms FLOAT NOT NULL,
+			provide_error TEXT NOT NULL,
+			holders INT NOT NULL,
+			success_att INT NOT NULL,
+			fail_att INT NOT NULL,
+			pr_holders TEXT[] NOT NULL,
+
+			UNIQUE(cid_hash, provider_idx),
+			FOREIGN KEY(cid_hash) REFERENCES cid_info(cid_hash)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_cid_providers_cid_hash		ON cid_providers (cid_hash);
+		CREATE INDEX IF NOT EXISTS idx_cid_providers_provider_id	ON cid_providers (provider_id);
+		`)
+	if err != nil {
+		return errors.Wrap(err, "error preparing statement for cid_providers table generation")
+	}
+	return nil
+}
+
+func (db *DBClient) addCidProvider(provider *models.CidProvider) persistable {
+	persis := newPersistable()
+	persis.query = `
+	INSERT INTO cid_providers (
+		cid_hash,
+		provider_idx,
+		provider_id,
+		host_id,
+		provide_delay_m,
+		provide_time,
+		provide_duration_ms,
+		provide_error,
+		holders,
+		success_att,
+		fail_att,
+		pr_holders)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+
+	event := provider.Event
+	tot, suc, fail := event.Results.GetSummary()
+	// only the peers that accepted the ADD_PROVIDER of this provider
+	prHolders := make([]string, 0, suc)
+	for _, pingRes := range event.Results.PRPingResults {
+		if pingRes.Active {
+			prHolders = append(prHolders, pingRes.PeerID.String())
+		}
+	}
+	persis.values = append(persis.values,
+		event.Cid.Hash().B58String(),
+		provider.Index,
+		provider.Provider.String(),
+		provider.HostID,
+		provider.Delay.Minutes(),
+		event.ProvideTime,
+		event.ProvideDuration.Milliseconds(),
+		event.Error,
+		tot,
+		suc,
+		fail,
+		prHolders)
+
+	return persis
+}
@@ -108,9 +108,43 @@ func (db *DBClient) AddCidInfo(c *models.CidInfo) {
 		"cid":        c.CID.String(),
 	}).Trace("new event to perstist")
 
+	// the PR Holders at this point are the ones of the initial publication (provide round 0)
+	prHolders := c.GetPRHolders()
 	db.persistC <- db.addCidInfo(c)
-	db.persistC <- db.addNewPeerInfoSet(c.PRHolders)
-	db.persistC <- db.addPRHoldersSet(c.CID, c.PRHolders)
+	db.persistC <- db.addNewPeerInfoSet(prHolders)
+	db.persistC <- db.addPRHoldersSet(c.CID, 0, prHolders)
+}
+
+// AddProvideEvent persists a provide round of the CID, including the PR Holders of the republishes
+// (the ones of the initial publication are persisted together with the cid_info)
+func (db *DBClient) AddProvideEvent(c *models.CidInfo, event *models.ProvideEvent) {
+	log.WithFields(log.Fields{
+		"event_type":    "provide_events",
+		"cid":           event.Cid.Hash().B58String(),
+		"provide_round": event.Round,
+	}).Trace("new event to perstist")
+
+	db.persistC <- db.addProvideEvent(event, c.PublishTime)
+	if event.Round > 0 {
+		prHolders := event.GetPRHolders()
+		db.persistC <- db.addNewPeerInfoSet(prHolders)
+		db.persistC <- db.addPRHoldersSet(event.Cid, event.Round, prHolders)
+		db.persistC <- db.addLookupSteps(event.Results.Lookup)
+	}
+}
+
+// AddProvideAttempts persists each of the attempts of the initial publication of the CID with its own
+// PR Holder results (also the ones that didn't reach enough PR Holders)
+func (db *DBClient) AddProvideAttempts(attempts []*models.ProvideEvent) {
+	for _, attempt := range attempts {
+		log.WithFields(log.Fields{
+			"event_type": "provide_attempts",
+			"cid":        attempt.Cid.Hash().B58String(),
+			"attempt":    attempt.Attempt,
+		}).Trace("new event to perstist")
+
+		db.persistC <- db.addProvideAttempt(attempt)
+	}
 }
 
 func (db *DBClient) AddPeerInfo(p *models.PeerInfo) {
@@ -129,12 +163,57 @@ func (db *DBClient) AddFetchResult(f *models.CidFetchResults) {
 	}).Trace("new event to perstist")
 
 	db.persistC <- db.addFetchResults(f)
+	db.persistC <- db.addNewPeerInfoSet(f.ClosestPeersInfo)
 	db.persistC <- db.addPingResultsSet(f.PRPingResults)
 	db.persistC <- db.addClosestPeerSet(&models.ClosestPeers{
 		Cid:       f.Cid,
 		PingRound: f.Round,
 		Peers:     f.ClosestPeers,
 	})
+	db.persistC <- db.addLookupSteps(f.Lookup)
+	db.persistC <- db.addProviderResults(f)
+	db.persistC <- db.addRoundProviders(f)
+	db.persistC <- db.addIpnsPingResultsSet(f)
+}
+
+// AddCidProvider persists a provider of the CID with its provide, and the peer_info of its PR Holders
+func (db *DBClient) AddCidProvider(provider *models.CidProvider) {
+	log.WithFields(log.Fields{
+		"event_type": "cid_providers",
+		"cid":        provider.Event.Cid.Hash().B58String(),
+		"provider":   provider.Index,
+	}).Trace("new event to perstist")
+
+	db.persistC <- db.addCidProvider(provider)
+	// the PR Holders of the provider are pinged on every round, so they need their peer_info
+	db.persistC <- db.addNewPeerInfoSet(provider.Event.GetPRHolders())
+}
+
+// AddIpnsRecord persists the IPNS record of the CID, the peer_info of its holders and the holder set
+func (db *DBClient) AddIpnsRecord(record *models.IpnsRecord) {
+	log.WithFields(log.Fields{
+		"event_type": "ipns_records",
+		"cid":        record.Cid.Hash().B58String(),
+		"name":       record.Name.String(),
+	}).Trace("new event to perstist")
+
+	db.persistC <- db.addIpnsRecord(record)
+	db.persistC <- db.addNewPeerInfoSet(record.GetHolders())
+	db.persistC <- db.addIpnsHoldersSet(record)
+}
+
+// AddClosestPeersOverlap persists the comparison of the closest peers of a ping round with the original PR Holders
+func (db *DBClient) AddClosestPeersOverlap(overlap *models.ClosestPeersOverlap) {
+	if overlap == nil {
+		return
+	}
+	log.WithFields(log.Fields{
+		"event_type": "closest_peers_overlap",
+		"cid":        overlap.Cid.Hash().B58String(),
+		"ping_round": overlap.PingRound,
+	}).Trace("new event to perstist")
+
+	db.persistC <- db.addClosestPeersOverlap(overlap)
 }
 
 // persisterWorker is the main logic of each of the main DB client persisters
@@ -186,6 +265,11 @@ func (db *DBClient) persisterWorker(closeC chan struct{}, wg *sync.WaitGroup, pe
 // initTables creates all the necesary tables in the given DB
 func (db *DBClient) initTables() error {
 	var err error
+	// cid_generation table
+	err = db.CreateCidGenerationTable()
+	if err != nil {
+		return err
+	}
 	// cid_info table
 	err = db.CreateCidInfoTable()
 	if err != nil {
@@ -201,6 +285,16 @@ func (db *DBClient) initTables() error {
 	if err != nil {
 		return err
 	}
+	// provide_events table
+	err = db.CreateProvideEventsTable()
+	if err != nil {
+		return err
+	}
+	// provide_attempts table
+	err = db.CreateProvideAttemptsTable()
+	if err != nil {
+		return err
+	}
 	// fetch_results table
 	err = db.CreateFetchResultsTable()
 	if err != nil {
@@ -216,6 +310,46 @@ func (db *DBClient) initTables() error {
 	if err != nil {
 		return err
 	}
+	// lookup_steps
+	err = db.CreateLookupStepsTable()
+	if err != nil {
+		return err
+	}
+	// closest_peers_overlap
+	err = db.CreateClosestPeersOverlapTable()
+	if err != nil {
+		return err
+	}
+	// cid_providers
+	err = db.CreateCidProvidersTable()
+	if err != nil {
+		return err
+	}
+	// provider_results
+	err = db.CreateProviderResultsTable()
+	if err != nil {
+		return err
+	}
+	// round_providers
+	err = db.CreateRoundProvidersTable()
+	if err != nil {
+		return err
+	}
+	// ipns_records
+	err = db.CreateIpnsRecordsTable()
+	if err != nil {
+		return err
+	}
+	// ipns_holders
+	err = db.CreateIpnsHoldersTable()
+	if err != nil {
+		return err
+	}
+	// ipns_ping_results
+	err = db.CreateIpnsPingResultsTable()
+	if err != nil {
+		return err
+	}
 	return err
 }
 
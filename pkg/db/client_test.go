@@ -0,0 +1,53 @@
+package db
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
+
+	cid "github.com/ipfs/go-cid"
+)
+
+func TestCidProviderHoldersBeforePings(t *testing.T) {
+	db := &DBClient{persistC: make(chan persistable, 64)}
+	c := cid.Undef
+	holder := models.NewPeerInfo("holder", nil, "test")
+
+	event := models.NewProvideEvent(c, 0, 0, time.Now(), 20)
+	event.AddPRHolder(holder, false)
+	db.AddCidProvider(&models.CidProvider{Index: 1, Provider: "second", Event: event})
+
+	fetchRes := models.NewCidFetchResults(c, time.Now(), 1, 20)
+	fetchRes.AddProviderHolderPing(1, models.NewPRPingResults(c, holder.ID, 1, time.Now(), time.Now(), 0, true, true, true, ""))
+	db.AddFetchResult(fetchRes)
+	close(db.persistC)
+
+	// the peer_info of the PR Holders of the provider has to be persisted before their ping_results
+	peerInfoIdx, pingIdx := -1, -1
+	idx := 0
+	for persis := range db.persistC {
+		switch {
+		case strings.Contains(persis.query, "INSERT INTO peer_info") && containsValue(persis.values, holder.ID.String()):
+			if peerInfoIdx < 0 {
+				peerInfoIdx = idx
+			}
+		case strings.Contains(persis.query, "INSERT INTO ping_results"):
+			pingIdx = idx
+		}
+		idx++
+	}
+	if peerInfoIdx < 0 || pingIdx < 0 || peerInfoIdx > pingIdx {
+		t.Fatalf("expected the peer_info of the holder (%d) before its ping_results (%d)", peerInfoIdx, pingIdx)
+	}
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
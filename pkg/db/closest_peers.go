@@ -35,7 +35,7 @@ func (db *DBClient) CreateClosestPeersTable() error {
 func (db *DBClient) addClosestPeerSet(closestPeers *models.ClosestPeers) persistable {
 	persis := newPersistable()
 
-	if closestPeers.PingRound == 0 || len(closestPeers.Peers) <= 0 {
+	if len(closestPeers.Peers) <= 0 {
 		return persis
 	}
 
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+func (db *DBClient) CreateClosestPeersOverlapTable() error {
+	log.Debugf("creating table 'closest_peers_overlap' for DB")
+	_, err := db.psqlPool.Exec(db.ctx, `
+		CREATE TABLE IF NOT EXISTS closest_peers_overlap(
+			id SERIAL PRIMARY KEY,
+			cid_hash TEXT NOT NULL,
+			ping_round INT NOT NULL,
+			closest_peers INT NOT NULL,
+			pr_holders INT NOT NULL,
+			overlap INT NOT NULL,
+			joined_peers TEXT[] NOT NULL,
+			left_peers TEXT[] NOT NULL,
+
+			FOREIGN KEY(cid_hash) REFERENCES cid_info(cid_hash)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_closest_peers_overlap_cid_hash	ON closest_peers_overlap (cid_hash);
+		CREATE INDEX IF NOT EXISTS idx_closest_peers_overlap_ping_round	ON closest_peers_overlap (ping_round);
+		`)
+	if err != nil {
+		return errors.Wrap(err, "error preparing statement for closest_peers_overlap table generation")
+	}
+	return nil
+}
+
+func (db *DBClient) addClosestPeersOverlap(overlap *models.ClosestPeersOverlap) persistable {
+	persis := newPersistable()
+	if overlap == nil {
+		return persis
+	}
+	persis.query = `
+	INSERT INTO closest_peers_overlap (
+		cid_hash,
+		ping_round,
+		closest_peers,
+		pr_holders,
+		overlap,
+		joined_peers,
+		left_peers)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	joined := make([]string, 0, len(overlap.Joined))
+	for _, p := range overlap.Joined {
+		joined = append(joined, p.String())
+	}
+	left := make([]string, 0, len(overlap.Left))
+	for _, p := range overlap.Left {
+		left = append(left, p.String())
+	}
+	persis.values = append(persis.values,
+		overlap.Cid.Hash().B58String(),
+		overlap.PingRound,
+		overlap.ClosestPeers,
+		overlap.PRHolders,
+		overlap.Overlap,
+		joined,
+		left)
+
+	return persis
+}
@@ -30,8 +30,19 @@ func (db *DBClient) CreateFetchResultsTable() error {
 		fail_att INT NOT NULL,
 		is_retrievable BOOL NOT NULL,
 		pr_with_maddrs BOOL NOT NULL,
+		is_fetchable BOOL NOT NULL,
+		fetch_ttfb_ms FLOAT NOT NULL,
+		fetch_error TEXT NOT NULL,
+		creator_state TEXT NOT NULL,
+		providers_found INT NOT NULL,
+		first_provider TEXT NOT NULL,
+		routing_system TEXT NOT NULL,
+		routing_error TEXT NOT NULL,
+		planned_offset_m FLOAT NOT NULL,
+		fetch_dial_ms FLOAT NOT NULL,
+		fetched_from TEXT NOT NULL,
 
-		UNIQUE(cid_hash, ping_round),
+		UNIQUE(cid_hash, ping_round, routing_system),
 		FOREIGN KEY(cid_hash) REFERENCES cid_info(cid_hash)
 	);
 
@@ -66,8 +77,19 @@ func (db *DBClient) addFetchResults(fetchRes *models.CidFetchResults) persistabl
 		success_att,
 		fail_att,
 		is_retrievable,
-		pr_with_maddrs)		 
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`
+		pr_with_maddrs,
+		is_fetchable,
+		fetch_ttfb_ms,
+		fetch_error,
+		creator_state,
+		providers_found,
+		first_provider,
+		routing_system,
+		routing_error,
+		planned_offset_m,
+		fetch_dial_ms,
+		fetched_from)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27)`
 
 	tot, suc, fail := fetchRes.GetSummary()
 
@@ -87,7 +109,18 @@ func (db *DBClient) addFetchResults(fetchRes *models.CidFetchResults) persistabl
 		suc,
 		fail,
 		fetchRes.IsRetrievable,
-		fetchRes.PRWithMAddr)
+		fetchRes.PRWithMAddr,
+		fetchRes.IsFetchable,
+		fetchRes.FetchTTFB.Milliseconds(),
+		fetchRes.FetchError,
+		fetchRes.CreatorState,
+		fetchRes.ProvidersFound,
+		fetchRes.FirstProvider.String(),
+		fetchRes.RoutingSystem,
+		fetchRes.RoutingError,
+		fetchRes.PlannedOffset.Minutes(),
+		fetchRes.FetchDialDuration.Milliseconds(),
+		fetchRes.FetchedFrom.String())
 
 	return persis
 }
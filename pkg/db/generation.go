@@ -0,0 +1,84 @@
+package db
+
+import (
+	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+func (db *DBClient) CreateCidGenerationTable() error {
+	log.Debugf("creating table 'cid_generation' for DB")
+	_, err := db.psqlPool.Exec(db.ctx, `
+		CREATE TABLE IF NOT EXISTS cid_generation(
+			id SERIAL PRIMARY KEY,
+			start_time TIMESTAMP NOT NULL,
+			seed BIGINT NOT NULL,
+			source TEXT NOT NULL,
+			cid_file TEXT NOT NULL,
+			content_size INT NOT NULL,
+			content_size_dist TEXT NOT NULL,
+			cid_number INT NOT NULL,
+			cid_prefixes TEXT[] NOT NULL,
+			hash_target TEXT NOT NULL,
+			hash_target_budget_ms INT NOT NULL,
+			import_path TEXT NOT NULL,
+			chunker TEXT NOT NULL,
+			dag_layout TEXT NOT NULL,
+			provide_strategy TEXT NOT NULL,
+			ping_schedule TEXT NOT NULL
+		);`)
+	if err != nil {
+		return errors.Wrap(err, "error preparing statement for cid_generation table generation")
+	}
+	return nil
+}
+
+// AddGenerationParams persists the seed and parameters of the generation of the run straight away
+// (not through the persisters), as the ID of the generation is needed to link the CIDs to it
+func (db *DBClient) AddGenerationParams(params *models.GenerationParams) error {
+	log.WithFields(log.Fields{
+		"event_type": "cid_generation",
+		"seed":       params.Seed,
+	}).Trace("new event to perstist")
+
+	err := db.psqlPool.QueryRow(db.ctx, `
+		INSERT INTO cid_generation(
+			start_time,
+			seed,
+			source,
+			cid_file,
+			content_size,
+			content_size_dist,
+			cid_number,
+			cid_prefixes,
+			hash_target,
+			hash_target_budget_ms,
+			import_path,
+			chunker,
+			dag_layout,
+			provide_strategy,
+			ping_schedule)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING id;`,
+		params.StartTime,
+		params.Seed,
+		params.Source,
+		params.CidFile,
+		params.ContentSize,
+		params.ContentSizeDist,
+		params.CidNumber,
+		params.CidPrefixes,
+		params.HashTarget,
+		params.HashTargetBudget.Milliseconds(),
+		params.ImportPath,
+		params.Chunker,
+		params.DagLayout,
+		params.ProvideStrategy,
+		params.PingSchedule,
+	).Scan(&params.ID)
+	if err != nil {
+		return errors.Wrap(err, "persisting the generation params")
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+func (db *DBClient) CreateIpnsHoldersTable() error {
+	log.Debugf("creating table 'ipns_holders' for DB")
+	_, err := db.psqlPool.Exec(db.ctx, `
+		CREATE TABLE IF NOT EXISTS ipns_holders(
+			id SERIAL PRIMARY KEY,
+			cid_hash TEXT NOT NULL,
+			sequence BIGINT NOT NULL,
+			peer_id TEXT NOT NULL,
+			put_time TIMESTAMP NOT NULL,
+			put_duration_ms FLOAT NOT NULL,
+			accepted BOOL NOT NULL,
+			conn_error TEXT NOT NULL,
+
+			FOREIGN KEY(cid_hash) REFERENCES cid_info(cid_hash),
+			FOREIGN KEY(peer_id) REFERENCES peer_info(peer_id)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_ipns_holders_cid_hash	ON ipns_holders (cid_hash);
+		`)
+	if err != nil {
+		return errors.Wrap(err, "error preparing statement for ipns_holders table generation")
+	}
+	return nil
+}
+
+func (db *DBClient) addIpnsHoldersSet(record *models.IpnsRecord) persistable {
+	persis := newPersistable()
+	if len(record.PutResults) <= 0 {
+		return persis
+	}
+	persis.query = multiValueComposer(`
+		INSERT INTO ipns_holders (
+			cid_hash,
+			sequence,
+			peer_id,
+			put_time,
+			put_duration_ms,
+			accepted,
+			conn_error)`,
+		"",
+		len(record.PutResults), // number of values
+		7)                      // number of items per value
+
+	for _, putRes := range record.PutResults {
+		persis.values = append(persis.values,
+			record.Cid.Hash().B58String(),
+			int64(record.Sequence),
+			putRes.PeerID.String(),
+			putRes.PingTime,
+			putRes.PingDuration.Milliseconds(),
+			putRes.Active,
+			putRes.ConError)
+	}
+
+	return persis
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+func (db *DBClient) CreateIpnsPingResultsTable() error {
+	log.Debugf("creating table 'ipns_ping_results' for DB")
+	_, err := db.psqlPool.Exec(db.ctx, `
+		CREATE TABLE IF NOT EXISTS ipns_ping_results(
+			id SERIAL PRIMARY KEY,
+			cid_hash TEXT NOT NULL,
+			ping_round INT NOT NULL,
+			peer_id TEXT NOT NULL,
+			ping_time TIMESTAMP NOT NULL,
+			ping_time_since_publication_m FLOAT NOT NULL,
+			ping_duration_ms FLOAT NOT NULL,
+			is_active BOOL NOT NULL,
+			has_record BOOL NOT NULL,
+			sequence BIGINT NOT NULL,
+			is_valid BOOL NOT NULL,
+			conn_error TEXT NOT NULL,
+
+			UNIQUE(cid_hash, ping_round, peer_id),
+			FOREIGN KEY(cid_hash) REFERENCES cid_info(cid_hash),
+			FOREIGN KEY(peer_id) REFERENCES peer_info(peer_id)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_ipns_ping_results_cid_hash	ON ipns_ping_results (cid_hash);
+		CREATE INDEX IF NOT EXISTS idx_ipns_ping_results_ping_round	ON ipns_ping_results (ping_round);
+		`)
+	if err != nil {
+		return errors.Wrap(err, "error preparing statement for ipns_ping_results table generation")
+	}
+	return nil
+}
+
+func (db *DBClient) addIpnsPingResultsSet(fetchRes *models.CidFetchResults) persistable {
+	persis := newPersistable()
+	if len(fetchRes.IpnsPingResults) <= 0 {
+		return persis
+	}
+
+	persis.query = multiValueComposer(`
+		INSERT INTO ipns_ping_results (
+			cid_hash,
+			ping_round,
+			peer_id,
+			ping_time,
+			ping_time_since_publication_m,
+			ping_duration_ms,
+			is_active,
+			has_record,
+			sequence,
+			is_valid,
+			conn_error)`,
+		"",
+		len(fetchRes.IpnsPingResults), // number of values
+		11)                            // number of items per value
+
+	pubTime := fetchRes.GetPublicationTime()
+	for _, ping := range fetchRes.IpnsPingResults {
+		persis.values = append(persis.values,
+			ping.Cid.Hash().B58String(),
+			ping.Round,
+			ping.PeerID.String(),
+			ping.PingTime,
+			ping.PingTime.Add(ping.PingDuration).Sub(pubTime).Minutes(),
+			ping.PingDuration.Milliseconds(),
+			ping.Active,
+			ping.HasRecord,
+			ping.Sequence,
+			ping.Valid,
+			ping.ConError)
+	}
+
+	return persis
+}
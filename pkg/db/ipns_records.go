@@ -0,0 +1,69 @@
+package db
+
+import (
+	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+func (db *DBClient) CreateIpnsRecordsTable() error {
+	log.Debugf("creating table 'ipns_records' for DB")
+	_, err := db.psqlPool.Exec(db.ctx, `
+		CREATE TABLE IF NOT EXISTS ipns_records(
+			id SERIAL PRIMARY KEY,
+			cid_hash TEXT NOT NULL,
+			name TEXT NOT NULL,
+			sequence BIGINT NOT NULL,
+			eol TIMESTAMP NOT NULL,
+			publish_time TIMESTAMP NOT NULL,
+			publish_duration_ms FLOAT NOT NULL,
+			publish_error TEXT NOT NULL,
+			holders INT NOT NULL,
+			success_att INT NOT NULL,
+			fail_att INT NOT NULL,
+
+			UNIQUE(cid_hash, sequence),
+			FOREIGN KEY(cid_hash) REFERENCES cid_info(cid_hash)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_ipns_records_cid_hash	ON ipns_records (cid_hash);
+		CREATE INDEX IF NOT EXISTS idx_ipns_records_name		ON ipns_records (name);
+		`)
+	if err != nil {
+		return errors.Wrap(err, "error preparing statement for ipns_records table generation")
+	}
+	return nil
+}
+
+func (db *DBClient) addIpnsRecord(record *models.IpnsRecord) persistable {
+	persis := newPersistable()
+	persis.query = `
+	INSERT INTO ipns_records (
+		cid_hash,
+		name,
+		sequence,
+		eol,
+		publish_time,
+		publish_duration_ms,
+		publish_error,
+		holders,
+		success_att,
+		fail_att)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	tot, suc, fail := record.GetSummary()
+	persis.values = append(persis.values,
+		record.Cid.Hash().B58String(),
+		record.Name.String(),
+		int64(record.Sequence),
+		record.EOL,
+		record.PublishTime,
+		record.PublishDuration.Milliseconds(),
+		record.Error,
+		tot,
+		suc,
+		fail)
+
+	return persis
+}
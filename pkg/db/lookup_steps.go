@@ -0,0 +1,81 @@
+package db
+
+import (
+	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+func (db *DBClient) CreateLookupStepsTable() error {
+	log.Debugf("creating table 'lookup_steps' for DB")
+	_, err := db.psqlPool.Exec(db.ctx, `
+		CREATE TABLE IF NOT EXISTS lookup_steps(
+			id SERIAL PRIMARY KEY,
+			cid_hash TEXT NOT NULL,
+			round INT NOT NULL,
+			lookup_type TEXT NOT NULL,
+			peer_id TEXT NOT NULL,
+			hop INT NOT NULL,
+			referred_by TEXT NOT NULL,
+			query_time TIMESTAMP NOT NULL,
+			query_duration_ms FLOAT NOT NULL,
+			returned_peers TEXT[] NOT NULL,
+			conn_error TEXT NOT NULL,
+
+			FOREIGN KEY(cid_hash) REFERENCES cid_info(cid_hash)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_lookup_steps_cid_hash		ON lookup_steps (cid_hash);
+		CREATE INDEX IF NOT EXISTS idx_lookup_steps_round_type	ON lookup_steps (round, lookup_type);
+		`)
+	if err != nil {
+		return errors.Wrap(err, "error preparing statement for lookup_steps table generation")
+	}
+	return nil
+}
+
+func (db *DBClient) addLookupSteps(lookup *models.Lookup) persistable {
+	persis := newPersistable()
+	if lookup == nil || len(lookup.Steps) <= 0 {
+		return persis
+	}
+	persis.query = multiValueComposer(`
+		INSERT INTO lookup_steps (
+			cid_hash,
+			round,
+			lookup_type,
+			peer_id,
+			hop,
+			referred_by,
+			query_time,
+			query_duration_ms,
+			returned_peers,
+			conn_error)`,
+		"",                // no appendix to the query
+		len(lookup.Steps), // number of Values to insert
+		10)                // number of items per value
+
+	for _, step := range lookup.Steps {
+		returnedPeers := make([]string, 0, len(step.ReturnedPeers))
+		for _, p := range step.ReturnedPeers {
+			returnedPeers = append(returnedPeers, p.String())
+		}
+		referredBy := ""
+		if step.ReferredBy != "" {
+			referredBy = step.ReferredBy.String()
+		}
+		persis.values = append(persis.values,
+			lookup.Cid.Hash().B58String(),
+			lookup.Round,
+			lookup.Type,
+			step.Peer.String(),
+			step.Hop,
+			referredBy,
+			step.QueryTime,
+			step.QueryDuration.Milliseconds(),
+			returnedPeers,
+			step.Error)
+	}
+	return persis
+}
@@ -22,8 +22,10 @@ func (db *DBClient) CreatePingResultsTable() error {
 			has_records BOOL NOT NULL,
 			records_with_maddrs BOOL NOT NULL,
 			conn_error TEXT NOT NULL,
+			original_holder BOOL NOT NULL,
+			provider_idx INT NOT NULL,
 
-			UNIQUE(cid_hash, ping_round, peer_id),
+			UNIQUE(cid_hash, ping_round, peer_id, provider_idx),
 			FOREIGN KEY(cid_hash) REFERENCES cid_info(cid_hash),
 			FOREIGN KEY(peer_id) REFERENCES peer_info(peer_id)
 		);`)
@@ -50,10 +52,12 @@ func (db *DBClient) addPingResultsSet(pingRes []*models.PRPingResults) persistab
 			is_active,
 			has_records,
 			records_with_maddrs,
-			conn_error)`,
+			conn_error,
+			original_holder,
+			provider_idx)`,
 		"",
 		len(pingRes), // number of values
-		10)           // number of items per value
+		12)           // number of items per value
 
 	// insert each of the Peers holding the PR
 	for _, ping := range pingRes {
@@ -67,6 +71,8 @@ func (db *DBClient) addPingResultsSet(pingRes []*models.PRPingResults) persistab
 		persis.values = append(persis.values, ping.HasRecords)
 		persis.values = append(persis.values, ping.RecordsWithMAddrs)
 		persis.values = append(persis.values, ping.ConError)
+		persis.values = append(persis.values, ping.OriginalHolder)
+		persis.values = append(persis.values, ping.ProviderIdx)
 	}
 
 	return persis
@@ -14,6 +14,7 @@ func (db *DBClient) CreatePRHoldersTable() error {
 		CREATE TABLE IF NOT EXISTS pr_holders(
 			id SERIAL PRIMARY KEY, 
 			cid_hash TEXT NOT NULL,
+			provide_round INT NOT NULL,
 			peer_id TEXT NOT NULL,
 
 			FOREIGN KEY(cid_hash) REFERENCES cid_info(cid_hash),
@@ -25,7 +26,7 @@ func (db *DBClient) CreatePRHoldersTable() error {
 	return nil
 }
 
-func (db *DBClient) addPRHoldersSet(c cid.Cid, prHolders []*models.PeerInfo) persistable {
+func (db *DBClient) addPRHoldersSet(c cid.Cid, provideRound int, prHolders []*models.PeerInfo) persistable {
 	persis := newPersistable()
 	if len(prHolders) <= 0 {
 		return persis
@@ -33,14 +34,16 @@ func (db *DBClient) addPRHoldersSet(c cid.Cid, prHolders []*models.PeerInfo) per
 	persis.query = multiValueComposer(`
 		INSERT INTO pr_holders (
 			cid_hash,
+			provide_round,
 			peer_id)`,
 		"",             // no appendix to the query
 		len(prHolders), // number of Values to insert
-		2)              // number of items per value (cid_hash, peer_id)
+		3)              // number of items per value (cid_hash, provide_round, peer_id)
 
 	// add each of the items of the PR holders to the values
 	for _, p := range prHolders {
 		persis.values = append(persis.values, c.Hash().B58String())
+		persis.values = append(persis.values, provideRound)
 		persis.values = append(persis.values, p.ID.String())
 	}
 
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+func (db *DBClient) CreateProvideAttemptsTable() error {
+	log.Debugf("creating table 'provide_attempts' for DB")
+	_, err := db.psqlPool.Exec(db.ctx, `
+		CREATE TABLE IF NOT EXISTS provide_attempts(
+			id SERIAL PRIMARY KEY,
+			cid_hash TEXT NOT NULL,
+			provide_round INT NOT NULL,
+			attempt INT NOT NULL,
+			attempt_time TIMESTAMP NOT NULL,
+			provide_duration_ms FLOAT NOT NULL,
+			provide_error TEXT NOT NULL,
+			holders INT NOT NULL,
+			success_att INT NOT NULL,
+			fail_att INT NOT NULL,
+			success_holders TEXT[] NOT NULL,
+			failed_holders TEXT[] NOT NULL,
+
+			UNIQUE(cid_hash, provide_round, attempt),
+			FOREIGN KEY(cid_hash) REFERENCES cid_info(cid_hash)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_provide_attempts_cid_hash	ON provide_attempts (cid_hash);
+		`)
+	if err != nil {
+		return errors.Wrap(err, "error preparing statement for provide_attempts table generation")
+	}
+	return nil
+}
+
+func (db *DBClient) addProvideAttempt(event *models.ProvideEvent) persistable {
+	persis := newPersistable()
+	persis.query = `
+	INSERT INTO provide_attempts (
+		cid_hash,
+		provide_round,
+		attempt,
+		attempt_time,
+		provide_duration_ms,
+		provide_error,
+		holders,
+		success_att,
+		fail_att,
+		success_holders,
+		failed_holders)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	tot, suc, fail := event.Results.GetSummary()
+	successHolders := make([]string, 0, suc)
+	failedHolders := make([]string, 0, fail)
+	for _, pingRes := range event.Results.PRPingResults {
+		if pingRes.Active {
+			successHolders = append(successHolders, pingRes.PeerID.String())
+		} else {
+			failedHolders = append(failedHolders, pingRes.PeerID.String())
+		}
+	}
+	persis.values = append(persis.values,
+		event.Cid.Hash().B58String(),
+		event.Round,
+		event.Attempt,
+		event.ProvideTime,
+		event.ProvideDuration.Milliseconds(),
+		event.Error,
+		tot,
+		suc,
+		fail,
+		successHolders,
+		failedHolders)
+
+	return persis
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"time"
+
+	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+func (db *DBClient) CreateProvideEventsTable() error {
+	log.Debugf("creating table 'provide_events' for DB")
+	_, err := db.psqlPool.Exec(db.ctx, `
+		CREATE TABLE IF NOT EXISTS provide_events(
+			id SERIAL PRIMARY KEY,
+			cid_hash TEXT NOT NULL,
+			provide_round INT NOT NULL,
+			ping_round INT NOT NULL,
+			provide_time TIMESTAMP NOT NULL,
+			provide_time_since_publication_m FLOAT NOT NULL,
+			provide_duration_ms FLOAT NOT NULL,
+			total_hops INT NOT NULL,
+			hops_tree_depth INT NOT NULL,
+			min_hops_for_closest INT NOT NULL,
+			holders INT NOT NULL,
+			new_holders INT NOT NULL,
+			success_att INT NOT NULL,
+			fail_att INT NOT NULL,
+
+			UNIQUE(cid_hash, provide_round),
+			FOREIGN KEY(cid_hash) REFERENCES cid_info(cid_hash)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_provide_events_cid_hash		ON provide_events (cid_hash);
+		CREATE INDEX IF NOT EXISTS idx_provide_events_provide_round	ON provide_events (provide_round);
+		`)
+	if err != nil {
+		return errors.Wrap(err, "error preparing statement for provide_events table generation")
+	}
+	return nil
+}
+
+func (db *DBClient) addProvideEvent(event *models.ProvideEvent, pubTime time.Time) persistable {
+	persis := newPersistable()
+	persis.query = `
+	INSERT INTO provide_events (
+		cid_hash,
+		provide_round,
+		ping_round,
+		provide_time,
+		provide_time_since_publication_m,
+		provide_duration_ms,
+		total_hops,
+		hops_tree_depth,
+		min_hops_for_closest,
+		holders,
+		new_holders,
+		success_att,
+		fail_att)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+
+	tot, suc, fail := event.Results.GetSummary()
+	persis.values = append(persis.values,
+		event.Cid.Hash().B58String(),
+		event.Round,
+		event.PingRound,
+		event.ProvideTime,
+		event.ProvideTime.Sub(pubTime).Minutes(),
+		event.ProvideDuration.Milliseconds(),
+		event.Results.TotalHops,
+		event.Results.HopsTreeDepth,
+		event.Results.MinHopsToClosest,
+		tot,
+		event.NewPRHolders,
+		suc,
+		fail)
+
+	return persis
+}
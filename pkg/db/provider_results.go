@@ -0,0 +1,67 @@
+package db
+
+import (
+	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+func (db *DBClient) CreateProviderResultsTable() error {
+	log.Debugf("creating table 'provider_results' for DB")
+	_, err := db.psqlPool.Exec(db.ctx, `
+		CREATE TABLE IF NOT EXISTS provider_results(
+			id SERIAL PRIMARY KEY,
+			cid_hash TEXT NOT NULL,
+			ping_round INT NOT NULL,
+			provider_id TEXT NOT NULL,
+			found BOOL NOT NULL,
+			rank INT NOT NULL,
+			with_maddrs BOOL NOT NULL,
+			routing_system TEXT NOT NULL,
+
+			UNIQUE(cid_hash, ping_round, provider_id, routing_system),
+			FOREIGN KEY(cid_hash) REFERENCES cid_info(cid_hash)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_provider_results_cid_hash		ON provider_results (cid_hash);
+		CREATE INDEX IF NOT EXISTS idx_provider_results_ping_round		ON provider_results (ping_round);
+		`)
+	if err != nil {
+		return errors.Wrap(err, "error preparing statement for provider_results table generation")
+	}
+	return nil
+}
+
+func (db *DBClient) addProviderResults(fetchRes *models.CidFetchResults) persistable {
+	persis := newPersistable()
+	if len(fetchRes.ProviderResults) <= 0 {
+		return persis
+	}
+
+	persis.query = multiValueComposer(`
+		INSERT INTO provider_results (
+			cid_hash,
+			ping_round,
+			provider_id,
+			found,
+			rank,
+			with_maddrs,
+			routing_system)`,
+		"",
+		len(fetchRes.ProviderResults), // number of values
+		7)                             // number of items per value
+
+	for _, res := range fetchRes.ProviderResults {
+		persis.values = append(persis.values,
+			fetchRes.Cid.Hash().B58String(),
+			fetchRes.Round,
+			res.Provider.String(),
+			res.Found,
+			res.Rank,
+			res.WithMAddrs,
+			fetchRes.RoutingSystem)
+	}
+
+	return persis
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+func (db *DBClient) CreateRoundProvidersTable() error {
+	log.Debugf("creating table 'round_providers' for DB")
+	_, err := db.psqlPool.Exec(db.ctx, `
+		CREATE TABLE IF NOT EXISTS round_providers(
+			id SERIAL PRIMARY KEY,
+			cid_hash TEXT NOT NULL,
+			ping_round INT NOT NULL,
+			routing_system TEXT NOT NULL,
+			provider_id TEXT NOT NULL,
+			multi_addrs TEXT[] NOT NULL,
+			rank INT NOT NULL,
+			time_to_discovery_ms FLOAT NOT NULL,
+			hop INT NOT NULL,
+			discovered_by TEXT NOT NULL,
+			is_creator BOOL NOT NULL,
+
+			UNIQUE(cid_hash, ping_round, routing_system, provider_id),
+			FOREIGN KEY(cid_hash) REFERENCES cid_info(cid_hash)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_round_providers_cid_hash		ON round_providers (cid_hash);
+		CREATE INDEX IF NOT EXISTS idx_round_providers_provider_id	ON round_providers (provider_id);
+		`)
+	if err != nil {
+		return errors.Wrap(err, "error preparing statement for round_providers table generation")
+	}
+	return nil
+}
+
+func (db *DBClient) addRoundProviders(fetchRes *models.CidFetchResults) persistable {
+	persis := newPersistable()
+	if len(fetchRes.RoundProviders) <= 0 {
+		return persis
+	}
+
+	persis.query = multiValueComposer(`
+		INSERT INTO round_providers (
+			cid_hash,
+			ping_round,
+			routing_system,
+			provider_id,
+			multi_addrs,
+			rank,
+			time_to_discovery_ms,
+			hop,
+			discovered_by,
+			is_creator)`,
+		"ON CONFLICT DO NOTHING",
+		len(fetchRes.RoundProviders), // number of values
+		10)                           // number of items per value
+
+	for _, prov := range fetchRes.RoundProviders {
+		discoveredBy := ""
+		if prov.DiscoveredBy != "" {
+			discoveredBy = prov.DiscoveredBy.String()
+		}
+		persis.values = append(persis.values,
+			fetchRes.Cid.Hash().B58String(),
+			fetchRes.Round,
+			fetchRes.RoutingSystem,
+			prov.Provider.String(),
+			prov.MultiAddrs,
+			prov.Rank,
+			prov.TimeToDiscovery.Milliseconds(),
+			prov.Hop,
+			discoveredBy,
+			prov.IsCreator)
+	}
+
+	return persis
+}
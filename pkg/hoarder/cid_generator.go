@@ -4,15 +4,23 @@ import (
 	"context"
 	"math/rand"
 	"sync"
+	"time"
 
+	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
+
+	"github.com/ipfs/boxo/blockstore"
+	blocks "github.com/ipfs/go-block-format"
 	"github.com/ipfs/go-cid"
-	mh "github.com/multiformats/go-multihash"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
 var (
 	CidLimitError error = errors.New("limit of cids reached")
+
+	// MaxConsecutiveGenErrors is the number of errors in a row after which the generator gives up on its source
+	// (e.g. a hash target region too small to land CIDs in within the budget)
+	MaxConsecutiveGenErrors = 10
 )
 
 // CidGenerator composes the basic object that generates set of CIDs defined in the configuration
@@ -20,33 +28,43 @@ type CidGenerator struct {
 	ctx         context.Context
 	generatorWG *sync.WaitGroup
 
-	contentSize int
-	cidNumber   int
+	source   CidSource
+	genID    int
+	newCidC  chan *GeneratedCid
+	doneC    chan struct{}
+	doneNotC chan struct{}
+}
 
-	generator *randomCidGen
-	newCidC   chan *cid.Cid
-	doneC     chan struct{}
-	doneNotC  chan struct{}
+// GeneratedCid is the CID that the CidGenerator hands to the publisher, together with the info of its origin
+type GeneratedCid struct {
+	CID          cid.Cid
+	Source       string
+	TargetRegion string  // region of the hash space the CID was generated for (empty if not targeted)
+	DagRoot      cid.Cid // root of the DAG the CID belongs to (undefined if it's not part of a DAG)
+	ContentSize  int     // size in bytes of the content behind the CID (-1 if unknown)
+	GenerationID int     // generation (seed and params) of the run in the DB
+	Index        int     // position of the CID in the sequence of the source
 }
 
-// NewCidTracker generates a new instance of the CIDTracker
+// NewCidGenerator generates a new instance of the CidGenerator over the given CidSource
+// linking the generated CIDs to the given generation of the DB
 func NewCidGenerator(
 	ctx context.Context,
-	cSize, cNumber int) *CidGenerator {
+	source CidSource,
+	genID int) *CidGenerator {
 
 	return &CidGenerator{
 		ctx:         ctx,
 		generatorWG: new(sync.WaitGroup),
-		contentSize: cSize,
-		cidNumber:   cNumber,
-		generator:   newRandomCidGen(cSize, cNumber),
-		newCidC:     make(chan *cid.Cid, 1),
+		source:      source,
+		genID:       genID,
+		newCidC:     make(chan *GeneratedCid, 1),
 		doneC:       make(chan struct{}, 1),
 	}
 }
 
 // Generates cids depending on the cid source
-func (g *CidGenerator) Run() (chan *cid.Cid, *sync.WaitGroup) {
+func (g *CidGenerator) Run() (chan *GeneratedCid, *sync.WaitGroup) {
 	g.generatorWG.Add(1)
 	go func() {
 		glog := log.WithField("mod", "cid-generator")
@@ -55,6 +73,7 @@ func (g *CidGenerator) Run() (chan *cid.Cid, *sync.WaitGroup) {
 			glog.Info("successfully closed")
 			close(g.doneC)
 		}()
+		consecutiveErrors := 0
 		for {
 			select {
 			case <-g.ctx.Done():
@@ -64,16 +83,23 @@ func (g *CidGenerator) Run() (chan *cid.Cid, *sync.WaitGroup) {
 				glog.Info("controled shutdown detected")
 				return
 			default:
-				contId, err := g.generator.getNewCid()
+				genCid, err := g.source.GetNewCid()
 				switch err {
 				case CidLimitError:
-					glog.Infof("Cid mark reached: %d, clossing generator", g.cidNumber)
+					glog.Infof("no more CIDs from %s source, clossing generator", g.source.Type())
 					return
 				case nil:
-					glog.Infof("generated new CID %s", contId.Hash().B58String())
-					g.newCidC <- &contId
+					consecutiveErrors = 0
+					glog.Infof("generated new CID %s", genCid.CID.Hash().B58String())
+					genCid.GenerationID = g.genID
+					g.newCidC <- genCid
 				default:
-					glog.Error("Error generating new CID: %s", err.Error())
+					consecutiveErrors++
+					glog.Errorf("Error generating new CID: %s", err.Error())
+					if consecutiveErrors >= MaxConsecutiveGenErrors {
+						glog.Errorf("%d errors in a row from %s source, giving up on the generation", consecutiveErrors, g.source.Type())
+						return
+					}
 				}
 			}
 		}
@@ -85,45 +111,150 @@ func (g *CidGenerator) Close() {
 	g.doneC <- struct{}{}
 }
 
+// randomCidGen generates CIDs out of random content of the given size.
+// The content comes from a seeded generator, so the same seed and params always produce the same CIDs
 type randomCidGen struct {
-	contentSize int
+	ctx         context.Context
+	bstore      blockstore.Blockstore // where the content of the CIDs is kept to serve it
+	rng         *rand.Rand
+	contentSize *models.ContentSizeDist
+	// mix of CID formats that will be generated, picked randomly by their weight for each CID
+	prefixes    []models.CidPrefix
+	totalWeight int
+	// region of the hash space where the CIDs have to land (nil to generate them uniformly)
+	target       *models.HashTarget
+	targetBudget time.Duration
 	//keeps track of how many cids have been generated by the random cid gen struct
 	cidsGenerated int
 	//limit of how many cids to generate (-1 to run it contineously)
 	limit int
 }
 
-func newRandomCidGen(contentSize int, limit int) *randomCidGen {
+func newRandomCidGen(
+	bstore blockstore.Blockstore,
+	seed int64,
+	contentSize *models.ContentSizeDist,
+	limit int,
+	prefixes []models.CidPrefix,
+	target *models.HashTarget,
+	targetBudget time.Duration) *randomCidGen {
+
+	totalWeight := 0
+	for _, prefix := range prefixes {
+		totalWeight += prefix.Weight
+	}
 	return &randomCidGen{
+		ctx:           context.Background(),
+		bstore:        bstore,
+		rng:           rand.New(rand.NewSource(seed)),
 		contentSize:   contentSize,
+		prefixes:      prefixes,
+		totalWeight:   totalWeight,
+		target:        target,
+		targetBudget:  targetBudget,
 		cidsGenerated: 0,
 		limit:         limit,
 	}
 }
 
-// getRandomContent returns generates an array of random bytes with the given size and the composed CID of the content
-func (g *randomCidGen) getNewCid() (cid.Cid, error) {
+func (g *randomCidGen) Type() string {
+	return RandomCidSource
+}
+
+// GetNewCid generates an array of random bytes with the given size and returns the composed CID of the content
+func (g *randomCidGen) GetNewCid() (*GeneratedCid, error) {
 	if g.cidsGenerated >= g.limit && g.limit > 0 { // allow cid-number = -1 to propose continuously untill stop
-		return cid.Cid{}, CidLimitError
+		return nil, CidLimitError
+	}
+	if g.target != nil {
+		return g.getTargetedCid()
 	}
 
+	contID, content, err := g.sumRandomContent()
+	if err != nil {
+		return nil, err
+	}
+	if err := g.storeContent(contID, content); err != nil {
+		return nil, err
+	}
 	g.cidsGenerated++
-	// generate random bytes
-	content := make([]byte, g.contentSize)
-	rand.Read(content)
-
-	// configure the type of CID that we want
-	pref := cid.Prefix{
-		Version:  1,
-		Codec:    cid.Raw,
-		MhType:   mh.SHA2_256,
-		MhLength: -1,
+	return &GeneratedCid{
+		CID:         contID,
+		Source:      RandomCidSource,
+		ContentSize: len(content),
+		Index:       g.cidsGenerated - 1,
+	}, nil
+}
+
+// getTargetedCid keeps generating random CIDs until one lands in the target region (rejection sampling),
+// giving up if it can't find any within the time budget. The stratified regions are targeted in turns.
+// Note that a CID discarded for exhausting the budget breaks the reproducibility of the following ones,
+// as the number of candidates drawn from the seed depends on the speed of the machine
+func (g *randomCidGen) getTargetedCid() (*GeneratedCid, error) {
+	region := g.cidsGenerated % g.target.NumberOfRegions()
+	deadline := time.Now().Add(g.targetBudget)
+	attempts := 0
+	for time.Now().Before(deadline) {
+		attempts++
+		contID, content, err := g.sumRandomContent()
+		if err != nil {
+			return nil, err
+		}
+		if g.target.Matches(contID, region) {
+			if err := g.storeContent(contID, content); err != nil {
+				return nil, err
+			}
+			g.cidsGenerated++
+			log.WithField("mod", "cid-generator").Debugf("cid landed in %s after %d attempts",
+				g.target.RegionString(region), attempts)
+			return &GeneratedCid{
+				CID:          contID,
+				Source:       RandomCidSource,
+				TargetRegion: g.target.RegionString(region),
+				ContentSize:  len(content),
+				Index:        g.cidsGenerated - 1,
+			}, nil
+		}
+	}
+	return nil, errors.Errorf("no cid landed in %s after %d attempts (budget of %s)",
+		g.target.RegionString(region), attempts, g.targetBudget)
+}
+
+// sumRandomContent returns new random content and its CID, with the type of CID picked from the mix
+func (g *randomCidGen) sumRandomContent() (cid.Cid, []byte, error) {
+	// generate random bytes of the size sampled from the distribution
+	content := make([]byte, g.contentSize.Sample(g.rng))
+	g.rng.Read(content)
+
+	// get the CID of the content we just generated with the type of CID that we want
+	// (the content is not a valid dag-pb node, but the DHT only cares about the multihash)
+	contID, err := g.nextPrefix().Sum(content)
+	if err != nil {
+		return cid.Cid{}, nil, errors.Wrap(err, "composing CID")
 	}
+	return contID, content, nil
+}
 
-	// get the CID of the content we just generated
-	contID, err := pref.Sum(content)
+// storeContent keeps the content of the CID in the blockstore (if any) to serve it over Bitswap
+func (g *randomCidGen) storeContent(contID cid.Cid, content []byte) error {
+	if g.bstore == nil {
+		return nil
+	}
+	block, err := blocks.NewBlockWithCid(content, contID)
 	if err != nil {
-		return cid.Cid{}, errors.Wrap(err, "composing CID")
+		return errors.Wrap(err, "composing block")
+	}
+	return errors.Wrap(g.bstore.Put(g.ctx, block), "storing block")
+}
+
+// nextPrefix picks the prefix of the next CID from the mix, proportionally to the weight of each prefix
+func (g *randomCidGen) nextPrefix() cid.Prefix {
+	pick := g.rng.Intn(g.totalWeight)
+	for _, prefix := range g.prefixes {
+		if pick < prefix.Weight {
+			return prefix.Prefix
+		}
+		pick -= prefix.Weight
 	}
-	return contID, nil
+	return g.prefixes[len(g.prefixes)-1].Prefix
 }
@@ -0,0 +1,41 @@
+package hoarder
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// failingCidSource never manages to generate a CID, like a hash target region too small to hit
+type failingCidSource struct {
+	calls int
+}
+
+func (s *failingCidSource) Type() string { return "failing" }
+
+func (s *failingCidSource) GetNewCid() (*GeneratedCid, error) {
+	s.calls++
+	return nil, errors.New("no cid landed in the region")
+}
+
+func TestCidGeneratorGivesUp(t *testing.T) {
+	source := &failingCidSource{}
+	generator := NewCidGenerator(context.Background(), source, 0)
+	_, genWG := generator.Run()
+
+	done := make(chan struct{})
+	go func() {
+		genWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the generator kept retrying a failing source")
+	}
+	if source.calls != MaxConsecutiveGenErrors {
+		t.Fatalf("expected %d attempts, got %d", MaxConsecutiveGenErrors, source.calls)
+	}
+}
@@ -1,38 +1,64 @@
 package hoarder
 
 import (
-	"sort"
+	"container/heap"
 	"sync"
 	"time"
 
 	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
 )
 
-// cidSet is a simple s.eue of CIDs that allows rapid access to content through maps,
-// while being abel to sort the array by closer next ping time to determine which is
-// the next soonest peer to ping
+// cidSet keeps the tracked CIDs in a min-heap keyed on their next ping time, so that the soonest CID
+// to ping is always at the top, with O(log n) inserts, reschedules and removals. The map gives rapid
+// access to the content, and the update channel wakes up the ping orchester when new CIDs arrive
+// or when the tracker is done adding them
 type cidSet struct {
 	sync.RWMutex
 
-	cidMap   map[string]*models.CidInfo
-	cidArray []*models.CidInfo
+	cidMap  map[string]*cidItem
+	cidHeap cidHeap
 
-	init    bool
-	pointer int
+	addingDone bool // the tracker won't add more CIDs to the set
+	updateC    chan struct{}
 }
 
-// newCidQueue creates a new CidSet
+// cidItem is each of the CIDs of the heap, with the next ping time that it was sorted by
+type cidItem struct {
+	cidInfo  *models.CidInfo
+	nextPing time.Time
+	index    int // position in the heap
+}
+
+// newCidSet creates a new CidSet
 func newCidSet() *cidSet {
 	return &cidSet{
-		cidMap:   make(map[string]*models.CidInfo),
-		cidArray: make([]*models.CidInfo, 0),
-		init:     false,
-		pointer:  -1,
+		cidMap:  make(map[string]*cidItem),
+		cidHeap: make(cidHeap, 0),
+		updateC: make(chan struct{}, 1),
 	}
 }
 
-func (s *cidSet) isInit() bool {
-	return s.init
+// doneAdding flags that no more CIDs will be added to the set (i.e. the generation, publication or
+// discovery of the CIDs is over), so the study is done once the set gets empty
+func (s *cidSet) doneAdding() {
+	s.Lock()
+	defer s.Unlock()
+	s.addingDone = true
+	s.notifyUpdate()
+}
+
+func (s *cidSet) isDoneAdding() bool {
+	s.RLock()
+	defer s.RUnlock()
+	return s.addingDone
+}
+
+// notifyUpdate wakes up the orchester without blocking (a pending notification is enough)
+func (s *cidSet) notifyUpdate() {
+	select {
+	case s.updateC <- struct{}{}:
+	default:
+	}
 }
 
 func (s *cidSet) isCidAlready(c string) bool {
@@ -47,52 +73,58 @@ func (s *cidSet) addCid(c *models.CidInfo) {
 	s.Lock()
 	defer s.Unlock()
 
-	s.cidMap[c.CID.Hash().B58String()] = c
-	s.cidArray = append(s.cidArray, c)
-
-	if !s.init {
-		s.init = true
+	cStr := c.CID.Hash().B58String()
+	if _, ok := s.cidMap[cStr]; ok {
+		return
 	}
+	item := &cidItem{cidInfo: c, nextPing: c.NextPing}
+	s.cidMap[cStr] = item
+	heap.Push(&s.cidHeap, item)
+
+	// wake up the orchester, the new CID might be the soonest one
+	s.notifyUpdate()
 }
 
 func (s *cidSet) removeCid(cStr string) {
-	delete(s.cidMap, cStr)
-	// check if len of the s.eue is only one
-	if s.Len() == 1 {
-		s.cidArray = make([]*models.CidInfo, 0)
+	s.Lock()
+	defer s.Unlock()
+
+	item, ok := s.cidMap[cStr]
+	if !ok {
 		return
 	}
-	item := -1
-	for idx, c := range s.cidArray {
-		if c.CID.Hash().B58String() == cStr {
-			item = idx
-			break
-		}
-	}
-	// check if the item was found
-	if item >= 0 {
-		s.cidArray = append(s.cidArray[:item], s.cidArray[(item+1):]...)
-	}
+	delete(s.cidMap, cStr)
+	heap.Remove(&s.cidHeap, item.index)
 }
 
-// Iterators
+// rescheduleCid moves the CID to its place in the heap after its next ping time was updated
+func (s *cidSet) rescheduleCid(c *models.CidInfo) {
+	s.Lock()
+	defer s.Unlock()
+
+	item, ok := s.cidMap[c.CID.Hash().B58String()]
+	if !ok {
+		return
+	}
+	item.nextPing = c.NextPing
+	heap.Fix(&s.cidHeap, item.index)
+}
 
-func (s *cidSet) Next() bool {
+// nextReady returns the soonest CID to ping if its next ping time has already come
+// (the CID stays in the set until it's rescheduled or removed)
+func (s *cidSet) nextReady(now time.Time) (*models.CidInfo, bool) {
 	s.RLock()
 	defer s.RUnlock()
-	return s.pointer < s.Len() && s.pointer >= 0
-}
 
-func (s *cidSet) Cid() *models.CidInfo {
-	if s.pointer >= s.Len() {
-		return nil
+	if len(s.cidHeap) == 0 || s.cidHeap[0].nextPing.After(now) {
+		return nil, false
 	}
-	s.Lock()
-	defer func() {
-		s.pointer++
-		s.Unlock()
-	}()
-	return s.cidArray[s.pointer]
+	return s.cidHeap[0].cidInfo, true
+}
+
+// updated returns the channel that notifies when new CIDs are added to the set, or when no more will be added
+func (s *cidSet) updated() <-chan struct{} {
+	return s.updateC
 }
 
 // common usage
@@ -101,59 +133,67 @@ func (s *cidSet) getCid(cStr string) (*models.CidInfo, bool) {
 	s.RLock()
 	defer s.RUnlock()
 
-	c, ok := s.cidMap[cStr]
-	return c, ok
+	item, ok := s.cidMap[cStr]
+	if !ok {
+		return nil, false
+	}
+	return item.cidInfo, true
 }
 
 func (s *cidSet) getCidList() []*models.CidInfo {
 	s.RLock()
 	defer s.RUnlock()
-	cidList := make([]*models.CidInfo, s.Len())
-	cidList = append(cidList, s.cidArray...)
+	cidList := make([]*models.CidInfo, 0, len(s.cidHeap))
+	for _, item := range s.cidHeap {
+		cidList = append(cidList, item.cidInfo)
+	}
 	return cidList
 }
 
-func (s *cidSet) SortCidList() {
-	sort.Sort(s)
-	s.pointer = 0
-	return
-}
-
-// Swap is part of sort.Interface.
-func (s *cidSet) Swap(i, j int) {
-	s.Lock()
-	defer s.Unlock()
+func (s *cidSet) Len() int {
+	s.RLock()
+	defer s.RUnlock()
 
-	s.cidArray[i], s.cidArray[j] = s.cidArray[j], s.cidArray[i]
+	return len(s.cidHeap)
 }
 
-// Less is part of sort.Interface. We use c.PeerList.NextConnection as the value to sort by.
-func (s *cidSet) Less(i, j int) bool {
+// NextValidTimeToPing returns the soonest next ping time of the set, if there is any CID to ping
+func (s *cidSet) NextValidTimeToPing() (time.Time, bool) {
 	s.RLock()
 	defer s.RUnlock()
+	if len(s.cidHeap) == 0 {
+		return time.Time{}, false
+	}
+	return s.cidHeap[0].nextPing, true
+}
+
+// cidHeap implements heap.Interface, the locking is left to the cidSet
+type cidHeap []*cidItem
 
-	return s.cidArray[i].NextPing.Before(s.cidArray[j].NextPing)
+func (h cidHeap) Len() int { return len(h) }
+
+func (h cidHeap) Less(i, j int) bool {
+	return h[i].nextPing.Before(h[j].nextPing)
 }
 
-// Len is part of sort.Interface. We use the peer list to get the length of the array.
-func (s *cidSet) Len() int {
-	s.RLock()
-	defer s.RUnlock()
+func (h cidHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
 
-	return len(s.cidArray)
+func (h *cidHeap) Push(x any) {
+	item := x.(*cidItem)
+	item.index = len(*h)
+	*h = append(*h, item)
 }
 
-func (s *cidSet) NextValidTimeToPing() (time.Time, bool) {
-	var validTime time.Time
-	s.RLock()
-	defer s.RUnlock()
-	for _, cid := range s.cidArray {
-		nextping := cid.NextPing
-		// asume that the array is sorted
-		if validTime.IsZero() && !nextping.IsZero() {
-			validTime = nextping
-			return validTime, true
-		}
-	}
-	return validTime, false
+func (h *cidHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil // don't keep a reference to the removed item
+	item.index = -1
+	*h = old[:n-1]
+	return item
 }
@@ -0,0 +1,171 @@
+package hoarder
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
+
+	"github.com/ipfs/go-cid"
+	mh "github.com/multiformats/go-multihash"
+)
+
+// testCidInfos composes n CidInfos with random next ping times within the next hour
+func testCidInfos(tb testing.TB, n int, rng *rand.Rand) []*models.CidInfo {
+	now := time.Now()
+	cidInfos := make([]*models.CidInfo, 0, n)
+	for i := 0; i < n; i++ {
+		hash, err := mh.Sum(binary.AppendUvarint(nil, uint64(i)), mh.SHA2_256, -1)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		cidInfo := models.NewCidInfo(cid.NewCidV1(cid.Raw, hash), 20, time.Hour, 48*time.Hour, "test", "")
+		cidInfo.NextPing = now.Add(time.Duration(rng.Int63n(int64(time.Hour))))
+		cidInfos = append(cidInfos, cidInfo)
+	}
+	return cidInfos
+}
+
+func TestCidSetOrder(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	set := newCidSet()
+	cidInfos := testCidInfos(t, 100, rng)
+	for _, cidInfo := range cidInfos {
+		set.addCid(cidInfo)
+	}
+	// the first half is removed, and the second half pinged once an hour later
+	for _, cidInfo := range cidInfos[:50] {
+		set.removeCid(cidInfo.CID.Hash().B58String())
+	}
+	if set.Len() != 50 {
+		t.Fatalf("expected 50 cids after the removals, got %d", set.Len())
+	}
+
+	var last time.Time
+	for pinged := 0; pinged < 50; pinged++ {
+		cidInfo, ok := set.nextReady(time.Now().Add(time.Hour))
+		if !ok {
+			t.Fatalf("expected a cid ready after %d pings", pinged)
+		}
+		if cidInfo.NextPing.Before(last) {
+			t.Fatalf("cid %d out of order: %s before %s", pinged, cidInfo.NextPing, last)
+		}
+		last = cidInfo.NextPing
+		cidInfo.NextPing = cidInfo.NextPing.Add(2 * time.Hour)
+		set.rescheduleCid(cidInfo)
+	}
+	if _, ok := set.nextReady(time.Now().Add(time.Hour)); ok {
+		t.Fatal("no cid should be ready before their second ping")
+	}
+	nextPing, ok := set.NextValidTimeToPing()
+	if !ok || nextPing.Before(time.Now().Add(2*time.Hour)) {
+		t.Fatalf("wrong next ping time %s", nextPing)
+	}
+}
+
+// sortedCidSet is the previous cidSet, re-sorted after each ping while locking on each Less/Swap,
+// kept as the baseline of the benchmarks
+type sortedCidSet struct {
+	sync.RWMutex
+	cidArray []*models.CidInfo
+}
+
+func (s *sortedCidSet) Len() int {
+	s.RLock()
+	defer s.RUnlock()
+	return len(s.cidArray)
+}
+
+func (s *sortedCidSet) Less(i, j int) bool {
+	s.RLock()
+	defer s.RUnlock()
+	return s.cidArray[i].NextPing.Before(s.cidArray[j].NextPing)
+}
+
+func (s *sortedCidSet) Swap(i, j int) {
+	s.Lock()
+	defer s.Unlock()
+	s.cidArray[i], s.cidArray[j] = s.cidArray[j], s.cidArray[i]
+}
+
+func (s *sortedCidSet) removeCid(cStr string) {
+	for idx, c := range s.cidArray {
+		if c.CID.Hash().B58String() == cStr {
+			s.cidArray = append(s.cidArray[:idx], s.cidArray[(idx+1):]...)
+			return
+		}
+	}
+}
+
+var benchSizes = []int{1_000, 10_000, 100_000}
+
+// BenchmarkCidSetPing measures the scheduling of a ping: taking the soonest CID and moving it to its next ping time
+func BenchmarkCidSetPing(b *testing.B) {
+	for _, n := range benchSizes {
+		cidInfos := testCidInfos(b, n, rand.New(rand.NewSource(42)))
+
+		b.Run(fmt.Sprintf("heap-%d", n), func(b *testing.B) {
+			set := newCidSet()
+			for _, cidInfo := range cidInfos {
+				set.addCid(cidInfo)
+			}
+			farFuture := time.Now().Add(time.Duration(b.N+1) * time.Hour)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				cidInfo, _ := set.nextReady(farFuture)
+				cidInfo.NextPing = cidInfo.NextPing.Add(time.Hour)
+				set.rescheduleCid(cidInfo)
+			}
+		})
+
+		b.Run(fmt.Sprintf("sorted-%d", n), func(b *testing.B) {
+			set := &sortedCidSet{cidArray: append([]*models.CidInfo{}, cidInfos...)}
+			sort.Sort(set)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				cidInfo := set.cidArray[0]
+				cidInfo.NextPing = cidInfo.NextPing.Add(time.Hour)
+				sort.Sort(set)
+			}
+		})
+	}
+}
+
+// BenchmarkCidSetRemove measures the removal of the CIDs that finished their study
+func BenchmarkCidSetRemove(b *testing.B) {
+	for _, n := range benchSizes {
+		cidInfos := testCidInfos(b, n, rand.New(rand.NewSource(42)))
+
+		b.Run(fmt.Sprintf("heap-%d", n), func(b *testing.B) {
+			set := newCidSet()
+			for _, cidInfo := range cidInfos {
+				set.addCid(cidInfo)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				cidInfo := cidInfos[i%n]
+				set.removeCid(cidInfo.CID.Hash().B58String())
+				b.StopTimer()
+				set.addCid(cidInfo)
+				b.StartTimer()
+			}
+		})
+
+		b.Run(fmt.Sprintf("sorted-%d", n), func(b *testing.B) {
+			set := &sortedCidSet{cidArray: append([]*models.CidInfo{}, cidInfos...)}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				cidInfo := cidInfos[i%n]
+				set.removeCid(cidInfo.CID.Hash().B58String())
+				b.StopTimer()
+				set.cidArray = append(set.cidArray, cidInfo)
+				b.StartTimer()
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package hoarder
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
+
+	"github.com/ipfs/boxo/blockstore"
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-ipld-prime/codec/dagcbor"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Supported sources of CIDs
+const (
+	RandomCidSource   = "random-content-gen"
+	TextFileCidSource = "text-file"
+	JSONFileCidSource = "json-file"
+	CARFileCidSource  = "car-file"
+	UnixFSCidSource   = "unixfs-import"
+)
+
+// CidSource is the interface that any source of CIDs needs to implement to feed the CidGenerator
+type CidSource interface {
+	// Type returns the name of the source, which is persisted as the origin of each CID
+	Type() string
+	// GetNewCid returns the next CID of the source, or CidLimitError once the source is exhausted
+	GetNewCid() (*GeneratedCid, error)
+}
+
+// NewCidSource returns the CidSource that matches the source type of the generation params
+// (the seed, the prefixes and the hash target only apply to the generated CIDs, the ones read from files are fixed).
+// The content of the generated CIDs is stored in the given blockstore, so that it can be served to the network
+func NewCidSource(params *models.GenerationParams, bstore blockstore.Blockstore) (CidSource, error) {
+	switch params.Source {
+	case RandomCidSource:
+		prefixes, err := models.ParseCidPrefixes(params.CidPrefixes)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading the cid prefixes")
+		}
+		target, err := models.ParseHashTarget(params.HashTarget)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading the hash target")
+		}
+		sizeDist, err := models.ParseContentSizeDist(params.ContentSizeDist, params.ContentSize)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading the content size distribution")
+		}
+		return newRandomCidGen(
+			bstore,
+			params.Seed,
+			sizeDist,
+			params.CidNumber,
+			prefixes,
+			target,
+			params.HashTargetBudget), nil
+	case TextFileCidSource:
+		return newFileCidSource(params.Source, params.CidFile, params.CidNumber, readTextCids)
+	case JSONFileCidSource:
+		return newFileCidSource(params.Source, params.CidFile, params.CidNumber, readJSONCids)
+	case CARFileCidSource:
+		return newFileCidSource(params.Source, params.CidFile, params.CidNumber, readCARCids)
+	case UnixFSCidSource:
+		// the DAG is built with the version and multihash of the first prefix
+		prefixes, err := models.ParseCidPrefixes(params.CidPrefixes)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading the cid prefixes")
+		}
+		return newUnixFSCidSource(
+			bstore,
+			params.ImportPath,
+			params.Chunker,
+			params.DagLayout,
+			params.ProvideStrategy,
+			prefixes[0].Prefix,
+			params.CidNumber)
+	default:
+		return nil, errors.New("unknown cid source " + params.Source)
+	}
+}
+
+// fileCidSource serves the CIDs read from a file, already produced by other tools
+type fileCidSource struct {
+	sourceType string
+	cids       []cid.Cid
+	pointer    int
+}
+
+func newFileCidSource(
+	sourceType, path string,
+	limit int,
+	readFn func(io.Reader) ([]cid.Cid, error)) (*fileCidSource, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening cid file")
+	}
+	defer f.Close()
+
+	cids, err := readFn(f)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("reading cids from %s %s", sourceType, path))
+	}
+	log.WithField("mod", "cid-source").Infof("read %d cids from %s %s", len(cids), sourceType, path)
+	// allow cid-number = -1 to track the entire file
+	if limit > 0 && len(cids) > limit {
+		cids = cids[:limit]
+	}
+	return &fileCidSource{
+		sourceType: sourceType,
+		cids:       cids,
+	}, nil
+}
+
+func (s *fileCidSource) Type() string {
+	return s.sourceType
+}
+
+func (s *fileCidSource) GetNewCid() (*GeneratedCid, error) {
+	if s.pointer >= len(s.cids) {
+		return nil, CidLimitError
+	}
+	genCid := &GeneratedCid{
+		CID:         s.cids[s.pointer],
+		Source:      s.sourceType,
+		ContentSize: -1, // only the CIDs are read from the files
+		Index:       s.pointer,
+	}
+	s.pointer++
+	return genCid, nil
+}
+
+// readTextCids reads a CID per line, skipping empty lines and comments (#)
+func readTextCids(r io.Reader) ([]cid.Cid, error) {
+	cids := make([]cid.Cid, 0)
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		c, err := cid.Decode(line)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("line %d", lineNum))
+		}
+		cids = append(cids, c)
+	}
+	return cids, scanner.Err()
+}
+
+// jsonCidManifest is the format of the JSON manifests with the CIDs to track
+//
+//	{"cids": [{"cid": "bafkrei..."}, {"cid": "Qm..."}]}
+type jsonCidManifest struct {
+	Cids []struct {
+		Cid string `json:"cid"`
+	} `json:"cids"`
+}
+
+func readJSONCids(r io.Reader) ([]cid.Cid, error) {
+	var manifest jsonCidManifest
+	if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+		return nil, err
+	}
+	cids := make([]cid.Cid, 0, len(manifest.Cids))
+	for idx, entry := range manifest.Cids {
+		c, err := cid.Decode(entry.Cid)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("entry %d", idx))
+		}
+		cids = append(cids, c)
+	}
+	return cids, nil
+}
+
+// carV2Pragma is the CARv1 header {version: 2} that opens every CARv2 file
+var carV2Pragma = []byte{0xa1, 0x67, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x02}
+
+// maxCARSectionSize bounds the length prefix of the CAR sections, as it is read straight from the file
+// (Bitswap blocks are at most 2MiB, which leaves plenty of room for the CID and the header)
+const maxCARSectionSize = 4 << 20
+
+// size of the fixed CARv2 header after the pragma: characteristics(16) + data offset(8) + data size(8) + index offset(8)
+const carV2HeaderSize = 40
+
+// readCARCids returns the CIDs of all the blocks stored in a CARv1 or CARv2 file
+func readCARCids(r io.Reader) ([]cid.Cid, error) {
+	br := bufio.NewReader(r)
+	header, err := readCARSection(br)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading car header")
+	}
+	version, err := carHeaderVersion(header)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading car header")
+	}
+	switch {
+	case version == 2 && bytes.Equal(header, carV2Pragma):
+		// CARv2 wraps a CARv1 payload, locate it and read it as a CARv1
+		v2Header := make([]byte, carV2HeaderSize)
+		if _, err := io.ReadFull(br, v2Header); err != nil {
+			return nil, errors.Wrap(err, "reading carv2 header")
+		}
+		dataOffset := binary.LittleEndian.Uint64(v2Header[16:24])
+		dataSize := binary.LittleEndian.Uint64(v2Header[24:32])
+		readBytes := uint64(1 + len(carV2Pragma) + carV2HeaderSize)
+		if dataOffset < readBytes {
+			return nil, errors.New("malformed carv2 header, data offset overlaps the header")
+		}
+		if _, err := io.CopyN(io.Discard, br, int64(dataOffset-readBytes)); err != nil {
+			return nil, errors.Wrap(err, "seeking carv2 data payload")
+		}
+		return readCARCids(io.LimitReader(br, int64(dataSize)))
+	case version != 1:
+		return nil, errors.Errorf("unsupported car version %d", version)
+	}
+
+	cids := make([]cid.Cid, 0)
+	for {
+		section, err := readCARSection(br)
+		if err == io.EOF {
+			return cids, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "reading car section")
+		}
+		// each section is the CID followed by the block data
+		_, c, err := cid.CidFromBytes(section)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading cid of car section")
+		}
+		cids = append(cids, c)
+	}
+}
+
+// readCARSection reads a varint length-prefixed section of a CAR file
+func readCARSection(br *bufio.Reader) ([]byte, error) {
+	size, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	if size > maxCARSectionSize {
+		return nil, errors.Errorf("car section of %d bytes exceeds the %d bytes limit", size, maxCARSectionSize)
+	}
+	section := make([]byte, size)
+	if _, err := io.ReadFull(br, section); err != nil {
+		return nil, err
+	}
+	return section, nil
+}
+
+// carHeaderVersion decodes the DAG-CBOR header of a CAR file, returning its version
+func carHeaderVersion(header []byte) (int64, error) {
+	nb := basicnode.Prototype.Any.NewBuilder()
+	if err := dagcbor.Decode(nb, bytes.NewReader(header)); err != nil {
+		return 0, err
+	}
+	versionNode, err := nb.Build().LookupByString("version")
+	if err != nil {
+		return 0, errors.Wrap(err, "no version in car header")
+	}
+	return versionNode.AsInt()
+}
@@ -0,0 +1,215 @@
+package hoarder
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
+
+	"github.com/ipfs/boxo/blockstore"
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	mh "github.com/multiformats/go-multihash"
+)
+
+func testCids(t *testing.T, n int) []cid.Cid {
+	cids := make([]cid.Cid, 0, n)
+	for i := 0; i < n; i++ {
+		hash, err := mh.Sum([]byte{byte(i)}, mh.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cids = append(cids, cid.NewCidV1(cid.Raw, hash))
+	}
+	return cids
+}
+
+func appendCARSection(buf []byte, section []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(section)))
+	return append(buf, section...)
+}
+
+// carV1Header is the DAG-CBOR header {roots: [], version: 1}
+var carV1Header = []byte{0xa2, 0x65, 0x72, 0x6f, 0x6f, 0x74, 0x73, 0x80, 0x67, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x01}
+
+// composeCARv1 writes a minimal CARv1 (the roots are not interpreted by the reader)
+func composeCARv1(cids []cid.Cid) []byte {
+	car := appendCARSection(nil, carV1Header)
+	for _, c := range cids {
+		car = appendCARSection(car, append(c.Bytes(), []byte("block data")...))
+	}
+	return car
+}
+
+func checkCids(t *testing.T, got, expected []cid.Cid) {
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d cids, got %d", len(expected), len(got))
+	}
+	for i := range expected {
+		if !got[i].Equals(expected[i]) {
+			t.Fatalf("cid %d mismatch: %s != %s", i, got[i], expected[i])
+		}
+	}
+}
+
+func TestReadTextCids(t *testing.T) {
+	cids := testCids(t, 3)
+	content := "# cids from another tool\n" + cids[0].String() + "\n\n" + cids[1].String() + "\n  " + cids[2].String() + "\n"
+	got, err := readTextCids(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCids(t, got, cids)
+
+	if _, err := readTextCids(strings.NewReader("not-a-cid\n")); err == nil {
+		t.Fatal("expected error on malformed cid")
+	}
+}
+
+func TestReadJSONCids(t *testing.T) {
+	cids := testCids(t, 2)
+	content := `{"cids": [{"cid": "` + cids[0].String() + `", "extra": 1}, {"cid": "` + cids[1].String() + `"}]}`
+	got, err := readJSONCids(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCids(t, got, cids)
+}
+
+func TestReadCARCids(t *testing.T) {
+	cids := testCids(t, 4)
+	carV1 := composeCARv1(cids)
+
+	got, err := readCARCids(bytes.NewReader(carV1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCids(t, got, cids)
+
+	// wrap the same payload into a CARv2 with some padding before the data
+	padding := 7
+	dataOffset := 1 + len(carV2Pragma) + carV2HeaderSize + padding
+	carV2 := appendCARSection(nil, carV2Pragma)
+	v2Header := make([]byte, carV2HeaderSize)
+	binary.LittleEndian.PutUint64(v2Header[16:24], uint64(dataOffset))
+	binary.LittleEndian.PutUint64(v2Header[24:32], uint64(len(carV1)))
+	carV2 = append(carV2, v2Header...)
+	carV2 = append(carV2, make([]byte, padding)...)
+	carV2 = append(carV2, carV1...)
+	carV2 = append(carV2, []byte("trailing index")...)
+
+	got, err = readCARCids(bytes.NewReader(carV2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCids(t, got, cids)
+}
+
+func TestReadCARMalformed(t *testing.T) {
+	// a length prefix far beyond any block can't make the reader allocate it
+	oversized := binary.AppendUvarint(appendCARSection(nil, carV1Header), 1<<40)
+	if _, err := readCARCids(bytes.NewReader(oversized)); err == nil {
+		t.Fatal("expected error on oversized car section")
+	}
+	// the header {version: 3} isn't supported
+	v3Header := []byte{0xa1, 0x67, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x03}
+	if _, err := readCARCids(bytes.NewReader(appendCARSection(nil, v3Header))); err == nil {
+		t.Fatal("expected error on unsupported car version")
+	}
+}
+
+func TestSeededRandomCids(t *testing.T) {
+	params := &models.GenerationParams{
+		Seed:        42,
+		Source:      RandomCidSource,
+		ContentSize: 128,
+		CidNumber:   5,
+		CidPrefixes: []string{"v1/raw/sha2-256:1", "v0/dag-pb/sha2-256:1"},
+	}
+	bstore := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	generate := func(p *models.GenerationParams) []cid.Cid {
+		source, err := NewCidSource(p, bstore)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cids := make([]cid.Cid, 0, p.CidNumber)
+		for {
+			genCid, err := source.GetNewCid()
+			if err == CidLimitError {
+				return cids
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if genCid.Index != len(cids) {
+				t.Fatalf("wrong index %d for cid %d", genCid.Index, len(cids))
+			}
+			// the content has to be stored to serve it
+			if has, _ := bstore.Has(context.Background(), genCid.CID); !has {
+				t.Fatalf("content of cid %d was not stored", len(cids))
+			}
+			cids = append(cids, genCid.CID)
+		}
+	}
+
+	// the same seed and params have to produce the same CIDs
+	cids := generate(params)
+	checkCids(t, generate(params), cids)
+
+	other := *params
+	other.Seed = 43
+	if generate(&other)[0].Equals(cids[0]) {
+		t.Fatal("different seeds produced the same cid")
+	}
+}
+
+func TestUnixFSImport(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "small.txt"), []byte("small file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// big enough to be chunked in several blocks, without repeated chunks
+	big := make([]byte, 10*1024)
+	rand.New(rand.NewSource(42)).Read(big)
+	if err := os.WriteFile(filepath.Join(dir, "big.bin"), big, 0644); err != nil {
+		t.Fatal(err)
+	}
+	prefix, err := models.ParseCidPrefix(models.DefaultCidPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	importCids := func(strategy string) []*GeneratedCid {
+		source, err := newUnixFSCidSource(nil, dir, "size-1024", BalancedDagLayout, strategy, prefix.Prefix, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return source.cids
+	}
+
+	root := importCids(RootProvideStrategy)
+	if len(root) != 1 || !root[0].CID.Equals(root[0].DagRoot) {
+		t.Fatalf("the root strategy has to provide only the root of the dag, got %d cids", len(root))
+	}
+	// the directory and its two files
+	roots := importCids(FileRootsProvideStrategy)
+	if len(roots) != 3 || !roots[0].CID.Equals(root[0].CID) {
+		t.Fatalf("the roots strategy has to provide the dir and its files first, got %d cids", len(roots))
+	}
+	// the directory, both files and the 10 chunks of the big one
+	all := importCids(AllProvideStrategy)
+	if len(all) != 13 {
+		t.Fatalf("the all strategy has to provide every block of the dag, got %d cids", len(all))
+	}
+	for i, c := range all {
+		if !c.DagRoot.Equals(root[0].CID) || c.Index != i {
+			t.Fatalf("cid %d is not linked to the root of the dag", i)
+		}
+	}
+}
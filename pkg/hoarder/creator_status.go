@@ -0,0 +1,42 @@
+package hoarder
+
+import (
+	"sync"
+
+	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// creatorStatus keeps track of the online state of the publisher hosts, so that the pinger can tell
+// on each round whether the creator of the CID was online (the state of any other creator is unknown)
+type creatorStatus struct {
+	m      sync.RWMutex
+	online map[peer.ID]bool
+}
+
+func newCreatorStatus() *creatorStatus {
+	return &creatorStatus{
+		online: make(map[peer.ID]bool),
+	}
+}
+
+func (s *creatorStatus) setOnline(creator peer.ID, online bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.online[creator] = online
+}
+
+// getState returns whether the given creator is online, offline, or unknown if it isn't one of our hosts
+func (s *creatorStatus) getState(creator peer.ID) string {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	online, ok := s.online[creator]
+	switch {
+	case !ok:
+		return models.CreatorUnknown
+	case online:
+		return models.CreatorOnline
+	default:
+		return models.CreatorOffline
+	}
+}
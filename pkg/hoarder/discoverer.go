@@ -0,0 +1,293 @@
+package hoarder
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cortze/ipfs-cid-hoarder/pkg/db"
+	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
+	"github.com/cortze/ipfs-cid-hoarder/pkg/p2p"
+	"go.uber.org/atomic"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// DiscoveredProvOp is the ProvideOp of the CIDs that weren't provided by the hoarder, but already on the network
+const DiscoveredProvOp = "discovered"
+
+// CidDiscoverer tracks CIDs that have already been published in the network by others.
+// For each of the CIDs, it looks for their providers and for the peers holding their PRs,
+// composing the round 0 of the CID, and adding it to the cidSet for the normal ping rounds
+type CidDiscoverer struct {
+	ctx   context.Context
+	appWG *sync.WaitGroup
+
+	host         *p2p.DHTHost
+	DBCli        *db.DBClient
+	cidGenerator *CidGenerator
+
+	K           int
+	Workers     int
+	ReqInterval time.Duration
+	TaskTimeout time.Duration
+	CidPingTime time.Duration
+	// offsets since the discovery of each ping round
+	PingSchedule *models.PingSchedule
+
+	// main set of Cids that will keep track of them over the run
+	cidSet         *cidSet
+	metrics        *publisherMetrics
+	generationDone *atomic.Bool
+}
+
+func NewCidDiscoverer(
+	ctx context.Context,
+	appWG *sync.WaitGroup,
+	hostOpts p2p.DHTHostOptions,
+	db *db.DBClient,
+	generator *CidGenerator,
+	cidSet *cidSet,
+	k, workers int,
+	reqInterval, taskTimeout, cidPingTime time.Duration,
+	pingSchedule *models.PingSchedule,
+) (*CidDiscoverer, error) {
+
+	log.WithField("mod", "discoverer").Info("initializing...")
+	h, err := p2p.NewDHTHost( // host is already bootstrapped
+		ctx,
+		hostOpts,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "discoverer:")
+	}
+	log.WithField("mod", "discoverer").Info("initialized...")
+	return &CidDiscoverer{
+		ctx:            ctx,
+		appWG:          appWG,
+		host:           h,
+		DBCli:          db,
+		cidGenerator:   generator,
+		K:              k,
+		Workers:        workers,
+		ReqInterval:    reqInterval,
+		TaskTimeout:    taskTimeout,
+		CidPingTime:    cidPingTime,
+		PingSchedule:   pingSchedule,
+		cidSet:         cidSet,
+		metrics:        newPublisherMetrics(DiscoveredProvOp),
+		generationDone: atomic.NewBool(false),
+	}, nil
+}
+
+func (discoverer *CidDiscoverer) Run() {
+	defer discoverer.appWG.Done()
+
+	var discovererWG sync.WaitGroup
+	genDoneCs := make([]chan struct{}, 0, discoverer.Workers)
+	dlog := log.WithField("mod", "discoverer")
+
+	cidC, genWG := discoverer.cidGenerator.Run()
+	for discovererCounter := 0; discovererCounter < discoverer.Workers; discovererCounter++ {
+		discovererWG.Add(1)
+		generationDoneC := make(chan struct{})
+		go discoverer.discoveryProcess(
+			&discovererWG,
+			generationDoneC,
+			discovererCounter,
+			cidC,
+		)
+		genDoneCs = append(genDoneCs, generationDoneC)
+	}
+
+	genWG.Wait()
+	dlog.Info("generation process finished successfully")
+	discoverer.generationDone.Swap(true)
+	for _, generationDoneC := range genDoneCs {
+		generationDoneC <- struct{}{}
+		close(generationDoneC)
+	}
+
+	discovererWG.Wait()
+	dlog.Info("discovery process finished successfully")
+	// all the discovered CIDs are already in the set, the pinger can finish once they are done
+	discoverer.cidSet.doneAdding()
+
+	discoverer.host.Close()
+	dlog.Info("discoverer successfully closed")
+}
+
+// discoveryProcess reads the CIDs from the generator and composes their round 0,
+// tracking as creators all the providers that the network reports for the CID
+func (discoverer *CidDiscoverer) discoveryProcess(
+	discovererWG *sync.WaitGroup,
+	generationDoneC chan struct{},
+	discovererID int,
+	cidChannel chan *GeneratedCid) {
+
+	defer discovererWG.Done()
+
+	dlog := log.WithField("discoverer-id", discovererID)
+	dlog.Debugf("discoverer ready")
+
+	generationDone := false
+	minIterTicker := time.NewTicker(minIterTime)
+
+	for {
+		// check if the generation is done to finish the discoverer (with priority)
+		if generationDone && len(cidChannel) == 0 {
+			dlog.Info("no cid is waiting to be discovered, closing")
+			return
+		}
+		select {
+		case nextCid := <-cidChannel:
+			cidInfo, err := discoverer.discoverCid(nextCid)
+			if err != nil {
+				dlog.Warnf("unable to track cid %s - %s", nextCid.CID.Hash().B58String(), err.Error())
+				continue
+			}
+			discoverer.metrics.addCid(DiscoveredProvOp)
+
+			// persist the CID and its round 0
+			discoverer.DBCli.AddCidInfo(cidInfo)
+			discoverer.DBCli.AddFetchResult(cidInfo.PRPingResults[0])
+			discoverer.DBCli.AddClosestPeersOverlap(models.NewClosestPeersOverlap(cidInfo.PRPingResults[0], cidInfo.GetPRHolders()))
+
+			// the round 0 is complete, add it to the cidSet to start the ping rounds
+			discoverer.cidSet.addCid(cidInfo)
+
+			tot, success, _ := cidInfo.GetFetchResultSummaryOfRound(0)
+			dlog.Infof("Cid %s - %d providers | %d closest peers | %d PRHolders",
+				cidInfo.CID.Hash().B58String(), len(cidInfo.Creators), tot, success)
+
+		case <-discoverer.ctx.Done():
+			dlog.Debugf("shutdown detected, closing discoverer")
+			return
+
+		case <-generationDoneC:
+			dlog.Debug("generation done detected")
+			generationDone = true
+
+		case <-minIterTicker.C:
+			// keep checking if the generation has ended to close the routine
+		}
+		minIterTicker.Reset(minIterTime)
+	}
+}
+
+// discoverCid looks for the providers of the CID, and asks the closest peers to the CID whether they
+// hold its PRs, composing the CidInfo and the round 0 of the CID
+func (discoverer *CidDiscoverer) discoverCid(genCid *GeneratedCid) (*models.CidInfo, error) {
+	ctx, cancel := context.WithTimeout(discoverer.ctx, discoverer.TaskTimeout)
+	defer cancel()
+
+	// the creators of the CID are unknown until we find its providers
+	cidInfo := models.NewCidInfo(
+		genCid.CID,
+		discoverer.K,
+		discoverer.ReqInterval,
+		discoverer.CidPingTime,
+		DiscoveredProvOp,
+		"",
+	)
+	cidInfo.AddSource(genCid.Source)
+	cidInfo.AddGeneration(genCid.GenerationID, genCid.Index)
+	cidInfo.AddPingSchedule(discoverer.PingSchedule)
+	// there is no publication, the discovery is the reference time for the ping rounds
+	discoveryTime := time.Now()
+	cidInfo.AddPublicationTime(discoveryTime)
+
+	traceCtx, stopTrace := discoverer.host.TraceProviderLookup(ctx, cidInfo)
+	findProvDuration, providers, err := discoverer.host.FindProvidersOfCID(traceCtx, cidInfo)
+	provLookup := models.NewLookup(genCid.CID, 0, models.ProvidersLookup, stopTrace())
+	if err != nil && len(providers) == 0 {
+		return nil, errors.Wrap(err, "looking for providers")
+	}
+	if len(providers) == 0 {
+		return nil, errors.New("no providers found")
+	}
+	// track every discovered provider as creator of the content
+	for _, provider := range providers {
+		cidInfo.AddCreator(provider.ID)
+	}
+
+	fetchRes := models.NewCidFetchResults(genCid.CID, discoveryTime, 0, discoverer.K)
+	fetchRes.FindProvDuration = findProvDuration
+	fetchRes.AddProviders(cidInfo.GetCreators(), providers)
+	fetchRes.AddRoundProviders(models.NewRoundProviders(cidInfo.GetCreators(), providers, discoveryTime, findProvDuration, provLookup))
+	fetchRes.IsRetrievable = true
+	for _, provider := range providers {
+		if len(provider.Addrs) > 0 {
+			fetchRes.PRWithMAddr = true
+		}
+	}
+
+	traceCtx, stopTrace = discoverer.host.TraceLookup(ctx, cidInfo)
+	closestDuration, closestPeers, lookupMetrics, err := discoverer.host.GetClosestPeersToCid(traceCtx, cidInfo)
+	fetchRes.AddLookup(models.NewLookup(genCid.CID, 0, models.ClosestPeersLookup, stopTrace()))
+	if err != nil && len(closestPeers) == 0 {
+		return nil, errors.Wrap(err, "getting closest peers")
+	}
+	fetchRes.GetClosePeersDuration = closestDuration
+	if lookupMetrics == nil {
+		fetchRes.TotalHops = -1
+		fetchRes.HopsTreeDepth = -1
+		fetchRes.MinHopsToClosest = -1
+	} else {
+		fetchRes.TotalHops = lookupMetrics.GetTotalHops()
+		fetchRes.HopsTreeDepth = lookupMetrics.GetTreeDepth()
+		fetchRes.MinHopsToClosest = lookupMetrics.GetMinHopsForPeerSet(lookupMetrics.GetClosestPeers())
+	}
+
+	// ask each of the closest peers whether they keep the PRs of any of the providers
+	var wg sync.WaitGroup
+	for _, closestPeer := range closestPeers {
+		fetchRes.AddClosestPeer(closestPeer)
+		wg.Add(1)
+		go func(p peer.ID) {
+			defer wg.Done()
+			pingRes := discoverer.host.PingPRHolderOnCid(
+				ctx,
+				peer.AddrInfo{ID: p, Addrs: discoverer.host.GetMAddrsOfPeer(p)},
+				cidInfo)
+			pingRes.Round = 0
+			fetchRes.AddPRPingResults(pingRes)
+		}(closestPeer)
+	}
+	wg.Wait()
+	fetchRes.FinishTime = time.Now()
+
+	// only the closest peers that keep the records are tracked as PRHolders,
+	// but all of them need to be in the DB for the ping results of the round
+	for _, pingRes := range fetchRes.PRPingResults {
+		peerInfo := models.NewPeerInfo(
+			pingRes.PeerID,
+			discoverer.host.GetMAddrsOfPeer(pingRes.PeerID),
+			discoverer.host.GetUserAgentOfPeer(pingRes.PeerID),
+		)
+		if pingRes.HasRecords {
+			cidInfo.AddPRHolder(peerInfo)
+		} else {
+			discoverer.DBCli.AddPeerInfo(peerInfo)
+		}
+	}
+	if cidInfo.NumberOfPRHolders() == 0 {
+		return nil, errors.New("none of the closest peers keeps the PRs")
+	}
+
+	cidInfo.AddPRFetchResults(fetchRes)
+	return cidInfo, nil
+}
+
+func (discoverer *CidDiscoverer) Close() {
+	// close the generator and everything else will be closed in cascade
+	if !discoverer.generationDone.Load() {
+		discoverer.cidGenerator.Close()
+	}
+}
+
+func (discoverer *CidDiscoverer) GetTotalPublishedCids() map[string]uint64 {
+	return discoverer.metrics.getCidPublicationNumbers()
+}
@@ -8,8 +8,12 @@ import (
 	"github.com/cortze/ipfs-cid-hoarder/pkg/config"
 	"github.com/cortze/ipfs-cid-hoarder/pkg/db"
 	"github.com/cortze/ipfs-cid-hoarder/pkg/metrics"
+	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
 	"github.com/cortze/ipfs-cid-hoarder/pkg/p2p"
 
+	"github.com/ipfs/boxo/blockstore"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
@@ -20,34 +24,88 @@ type CidHoarder struct {
 	ctx context.Context
 	wg  *sync.WaitGroup
 
-	dbCli        *db.DBClient
-	cidSet       *cidSet
-	cidPublisher *CidPublisher
-	cidPinger    *CidPinger
-	prometheus   *metrics.PrometheusMetrics
+	dbCli      *db.DBClient
+	cidSet     *cidSet
+	cidTracker cidTracker
+	cidPinger  *CidPinger
+	scheduler  *pubScheduler // nil when the CIDs are discovered instead of published
+	prometheus *metrics.PrometheusMetrics
 
 	FinishedC chan struct{}
 }
 
+// cidTracker is the common interface of the services that feed the cidSet with new CIDs
+// (CidPublisher when publishing the CIDs, CidDiscoverer when they were already published)
+type cidTracker interface {
+	Run()
+	Close()
+	GetTotalPublishedCids() map[string]uint64
+}
+
 func NewCidHoarder(ctx context.Context, conf *config.Config) (*CidHoarder, error) {
 	var err error
 	var studyWG sync.WaitGroup
 	cidSet := newCidSet()
 
+	// by default, each CID is pinged every req-interval
+	pingSchedule, err := models.ParsePingSchedule(conf.PingSchedule, conf.ReqInterval.Duration)
+	if err != nil {
+		return nil, errors.Wrap(err, "initialise the ping schedule")
+	}
+
+	// ----- Compose the CidSource -----
+	// (before anything else, in case the CID file can't be read)
+	genParams := &models.GenerationParams{
+		StartTime:        time.Now(),
+		Seed:             conf.Seed,
+		Source:           conf.CidSource,
+		CidFile:          conf.CidFile,
+		ContentSize:      conf.CidContentSize,
+		ContentSizeDist:  conf.ContentSizeDist,
+		CidNumber:        conf.CidNumber,
+		CidPrefixes:      conf.CidPrefixes,
+		HashTarget:       conf.HashTarget,
+		HashTargetBudget: conf.HashTargetBudget.Duration,
+		ImportPath:       conf.ImportPath,
+		Chunker:          conf.Chunker,
+		DagLayout:        conf.DagLayout,
+		ProvideStrategy:  conf.ProvideStrategy,
+		PingSchedule:     pingSchedule.Spec,
+	}
+	if genParams.Seed == 0 {
+		// no seed was given, pick one anyway so that the run can be replayed
+		genParams.Seed = time.Now().UnixNano()
+	}
+	// content of the generated CIDs, served by the publisher over the entire study
+	contentStore := blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	cidSource, err := NewCidSource(genParams, contentStore)
+	if err != nil {
+		return nil, errors.Wrap(err, "initialise the cid source")
+	}
+
 	// ----- Compose the DB client -----
 	dbInstance, err := db.NewDBClient(ctx, conf.Database)
 	if err != nil {
 		return nil, errors.Wrap(err, "initialise the DB")
 	}
+	err = dbInstance.AddGenerationParams(genParams)
+	if err != nil {
+		return nil, errors.Wrap(err, "initialise the DB")
+	}
+	log.WithFields(log.Fields{
+		"generation-id": genParams.ID,
+		"seed":          genParams.Seed,
+	}).Info("cid generation params persisted")
 
 	// ------ Configure the settings for the Libp2p hosts ------
 	hostOpts := p2p.DHTHostOptions{
-		IP:             "0.0.0.0",
-		Port:           conf.Port,
-		ProvOp:         p2p.GetProvOpFromConf(conf.ProvideOperation),
-		WithNotifier:   false,
-		K:              conf.K,
-		BlacklistingUA: conf.BlacklistedUA,
+		IP:               "0.0.0.0",
+		Port:             conf.Port,
+		ProvOp:           p2p.GetProvOpFromConf(conf.ProvideOperation),
+		WithNotifier:     false,
+		K:                conf.K,
+		BlacklistingUA:   conf.BlacklistedUA,
+		DelegatedRouting: conf.DelegatedRouting,
 	}
 	if conf.BlacklistedUA != "" {
 		log.Infof("UA blacklisting activated -> crawling network to identify %s (might take 5-7mins)",
@@ -61,64 +119,113 @@ func NewCidHoarder(ctx context.Context, conf *config.Config) (*CidHoarder, error
 		hostOpts.BlacklistedPeers = crawlResutls.GetBlacklistedPeers()
 	}
 
-	//  ------ Study Parameters ---------
-	reqInterval, err := time.ParseDuration(conf.ReqInterval)
-	if err != nil {
-		return nil, errors.Wrap(err, "error parsing ReqInterval "+conf.ReqInterval)
-	}
-
-	pubInterval, err := time.ParseDuration(conf.PubInterval)
-	if err != nil {
-		return nil, errors.Wrap(err, "error parsing PubInterval "+conf.PubInterval)
-	}
-
-	taskTimeout, err := time.ParseDuration(conf.TaskTimeout)
-	if err != nil {
-		return nil, errors.Wrap(err, "error parsing TaskTimeout "+conf.TaskTimeout)
-	}
-
-	cidPingTime, err := time.ParseDuration(conf.CidPingTime)
-	if err != nil {
-		return nil, errors.Wrap(err, "error parsing StudyDuration "+conf.CidPingTime)
-	}
+	// online state of our creator hosts, shared by the publisher and the pinger
+	creatorStatus := newCreatorStatus()
 
 	// ----- Generate the CidPinger -----
 	pingerHostOpts := hostOpts
+	pingerHostOpts.WithBitswap = true              // to fetch the content from the providers
+	pingerHostOpts.ProvOp = p2p.StandardDHTProvide // the pinger doesn't provide, no need to crawl the network
 	cidPinger, err := NewCidPinger(
 		ctx,
 		&studyWG,
 		pingerHostOpts,
 		dbInstance,
-		reqInterval,
-		taskTimeout,
+		conf.ReqInterval.Duration,
+		conf.TaskTimeout.Duration,
 		conf.Pingers,
 		conf.Hosts,
-		cidSet)
+		cidSet,
+		creatorStatus,
+		conf.ProviderLookupTarget)
 	if err != nil {
 		return nil, err
 	}
 
-	// ---- Generate the CidPublisher -----
-	// select the provide operation that we want to perform:
-	publisherHostOpts := hostOpts
-	publisherHostOpts.WithNotifier = true // the only time were want to have the notifier
-	cidPublisher, err := NewCidPublisher(
-		ctx,
-		&studyWG,
-		publisherHostOpts,
-		dbInstance,
-		NewCidGenerator(
+	cidGenerator := NewCidGenerator(ctx, cidSource, genParams.ID)
+	var tracker cidTracker
+	var pubScheduler *pubScheduler // only the publisher follows a publication schedule
+	if conf.AlreadyPublishedCids {
+		// ---- Generate the CidDiscoverer -----
+		tracker, err = NewCidDiscoverer(
 			ctx,
-			conf.CidContentSize,
-			conf.CidNumber,
-		),
-		cidSet,
-		conf.K,
-		conf.Publishers,
-		reqInterval,
-		pubInterval,
-		cidPingTime,
-	)
+			&studyWG,
+			hostOpts,
+			dbInstance,
+			cidGenerator,
+			cidSet,
+			conf.K,
+			conf.Publishers,
+			conf.ReqInterval.Duration,
+			conf.TaskTimeout.Duration,
+			conf.CidPingTime.Duration,
+			pingSchedule,
+		)
+	} else {
+		// ---- Generate the CidPublisher -----
+		// by default, each of the publishers publishes a CID every pub-interval
+		var pubSchedule *models.PubSchedule
+		pubSchedule, err = models.ParsePubSchedule(
+			conf.PubSchedule,
+			float64(conf.Publishers)/conf.PubInterval.Seconds())
+		if err != nil {
+			return nil, errors.Wrap(err, "initialise the publication schedule")
+		}
+		pubScheduler = newPubScheduler(ctx, pubSchedule, genParams.Seed, conf.Publishers)
+		retryPolicy := ProvideRetryPolicy{
+			Retries:      conf.ProvideRetries,
+			Backoff:      conf.ProvideRetryBackoff.Duration,
+			MinPRHolders: conf.MinPRHolders,
+			OnFailure:    models.DegradedCid,
+		}
+		if retryPolicy.MinPRHolders == 0 {
+			retryPolicy.MinPRHolders = conf.K
+		}
+		if conf.UnderReplicated == "discard" {
+			retryPolicy.OnFailure = models.DiscardedCid
+		}
+		var offlineWindows []models.OfflineWindow
+		offlineWindows, err = models.ParseOfflineWindows(conf.CreatorOffline)
+		if err != nil {
+			return nil, errors.Wrap(err, "initialise the creator offline windows")
+		}
+		var ipnsValidity time.Duration
+		if conf.IpnsRecords {
+			ipnsValidity = conf.IpnsValidity.Duration
+		}
+		// select the provide operation that we want to perform:
+		publisherHostOpts := hostOpts
+		publisherHostOpts.WithNotifier = true // the only time were want to have the notifier
+		publisherHostOpts.WithBitswap = true
+		publisherHostOpts.Blockstore = contentStore
+		tracker, err = NewCidPublisher(
+			ctx,
+			&studyWG,
+			cidPinger.Done(),
+			publisherHostOpts,
+			dbInstance,
+			cidGenerator,
+			cidSet,
+			pubScheduler,
+			creatorStatus,
+			PublisherOptions{
+				K:                 conf.K,
+				Workers:           conf.Publishers,
+				Hosts:             conf.PublisherHosts,
+				ProvideBatchSize:  conf.ProvideBatchSize,
+				ProvidersPerCid:   conf.ProvidersPerCid,
+				ReqInterval:       conf.ReqInterval.Duration,
+				PubInterval:       conf.PubInterval.Duration,
+				CidPingTime:       conf.CidPingTime.Duration,
+				RepublishInterval: conf.RepublishInterval.Duration,
+				ProviderStagger:   conf.ProviderStagger.Duration,
+				RetryPolicy:       retryPolicy,
+				OfflineWindows:    offlineWindows,
+				IpnsValidity:      ipnsValidity,
+				PingSchedule:      pingSchedule,
+			},
+		)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -129,21 +236,22 @@ func NewCidHoarder(ctx context.Context, conf *config.Config) (*CidHoarder, error
 		conf.MetricsPort)
 
 	cidHoarder := &CidHoarder{
-		ctx:          ctx,
-		wg:           &studyWG,
-		dbCli:        dbInstance,
-		cidSet:       cidSet,
-		cidPublisher: cidPublisher,
-		cidPinger:    cidPinger,
-		prometheus:   prometheusMetrics,
-		FinishedC:    make(chan struct{}, 1),
+		ctx:        ctx,
+		wg:         &studyWG,
+		dbCli:      dbInstance,
+		cidSet:     cidSet,
+		cidTracker: tracker,
+		cidPinger:  cidPinger,
+		scheduler:  pubScheduler,
+		prometheus: prometheusMetrics,
+		FinishedC:  make(chan struct{}, 1),
 	}
 	return cidHoarder, nil
 }
 
 func (c *CidHoarder) Run() error {
 	c.wg.Add(1)
-	go c.cidPublisher.Run()
+	go c.cidTracker.Run()
 	c.wg.Add(1)
 	go c.cidPinger.Run()
 
@@ -155,7 +263,7 @@ func (c *CidHoarder) Run() error {
 	hlog := log.WithField("mod", "hoarder")
 	go func() {
 		c.wg.Wait()
-		hlog.Info("publisher and pinger successfully closed")
+		hlog.Info("publisher/discoverer and pinger successfully closed")
 		c.dbCli.Close()
 		c.prometheus.Close()
 		hlog.Info("run finished, organically closed")
@@ -166,6 +274,6 @@ func (c *CidHoarder) Run() error {
 
 func (c *CidHoarder) Close() {
 	log.Info("hoarder interruption detected!")
-	c.cidPublisher.Close()
+	c.cidTracker.Close()
 	c.cidPinger.Close()
 }
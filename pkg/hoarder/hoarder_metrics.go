@@ -38,6 +38,18 @@ var (
 	},
 		[]string{"host_id"},
 	)
+	publicationRate = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: hoarderModeName,
+		Name:      "publication_rate",
+		Help:      "Publications per minute that the publication schedule aims for (target) and the ones handed out to the publishers (achieved)",
+	},
+		[]string{"rate"},
+	)
+	droppedPubSlots = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: hoarderModeName,
+		Name:      "dropped_pub_slots",
+		Help:      "Number of publication slots dropped because all the publishers were busy",
+	})
 )
 
 func (h *CidHoarder) GetMetrics() *metrics.MetricsModule {
@@ -52,6 +64,9 @@ func (h *CidHoarder) GetMetrics() *metrics.MetricsModule {
 		h.secsToNextPingMetrics(),
 		h.totalPublishedCidsMetrics(),
 		h.pingingCidsperHostMetrics())
+	if h.scheduler != nil {
+		metricsMod.AddIndvMetric(h.publicationRateMetrics())
+	}
 	return metricsMod
 }
 
@@ -110,7 +125,7 @@ func (h *CidHoarder) totalPublishedCidsMetrics() *metrics.IndvMetrics {
 		return nil
 	}
 	updateFn := func() (interface{}, error) {
-		totalCids := h.cidPublisher.GetTotalPublishedCids()
+		totalCids := h.cidTracker.GetTotalPublishedCids()
 		for op, val := range totalCids {
 			totalPublishedCids.WithLabelValues(op).Set(float64(val))
 		}
@@ -155,3 +170,32 @@ func (h *CidHoarder) pingingCidsperHostMetrics() *metrics.IndvMetrics {
 	}
 	return indvMetrics
 }
+
+func (h *CidHoarder) publicationRateMetrics() *metrics.IndvMetrics {
+	initFn := func() error {
+		prometheus.MustRegister(publicationRate)
+		prometheus.MustRegister(droppedPubSlots)
+		return nil
+	}
+	updateFn := func() (interface{}, error) {
+		rates := map[string]float64{
+			"target":   h.scheduler.targetRate(),
+			"achieved": h.scheduler.achievedRate(),
+		}
+		for rate, val := range rates {
+			publicationRate.WithLabelValues(rate).Set(val)
+		}
+		droppedPubSlots.Set(float64(h.scheduler.getDroppedSlots()))
+		return rates, nil
+	}
+
+	indvMetrics, err := metrics.NewIndvMetrics(
+		"publication_rate",
+		initFn,
+		updateFn)
+	if err != nil {
+		log.WithField("mod", "hoarder-metrics").Error(err)
+		return nil
+	}
+	return indvMetrics
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/cortze/ipfs-cid-hoarder/pkg/db"
 	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
 	"github.com/cortze/ipfs-cid-hoarder/pkg/p2p"
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/pkg/errors"
 
 	log "github.com/sirupsen/logrus"
@@ -35,9 +36,13 @@ type CidPinger struct {
 	taskTimeout  time.Duration
 	workers      int
 
+	provLookupTarget int // providers to look for on each round (0 for all of them)
+
 	cidS          *cidSet
+	creatorStatus *creatorStatus
 	pingTaskC     chan pingTask
 	closePingerCs []chan struct{}
+	doneC         chan struct{}
 }
 
 type pingTask struct {
@@ -53,7 +58,9 @@ func NewCidPinger(
 	dbCli *db.DBClient,
 	pingInterval, taskTimeout time.Duration,
 	workers, hosts int,
-	cidSet *cidSet) (*CidPinger, error) {
+	cidSet *cidSet,
+	creatorStatus *creatorStatus,
+	providerLookupTarget int) (*CidPinger, error) {
 
 	log.WithField("mod", "pinger").Info("initializing...")
 	// Hack: multiple pinger hosts to have concurrency calls:
@@ -78,10 +85,13 @@ func NewCidPinger(
 		dbCli:            dbCli,
 		pingInterval:     pingInterval,
 		taskTimeout:      taskTimeout,
+		provLookupTarget: providerLookupTarget,
 		pingTaskC:        make(chan pingTask, workers),
 		workers:          workers,
 		cidS:             cidSet,
+		creatorStatus:    creatorStatus,
 		closePingerCs:    make([]chan struct{}, 0, workers),
+		doneC:            make(chan struct{}),
 	}, nil
 }
 
@@ -115,29 +125,73 @@ func (pinger *CidPinger) Run() {
 
 	close(pinger.pingTaskC)
 	pinger.hostPool.Close()
+	close(pinger.doneC)
 	plog.Info("successfully closed")
 }
 
 // runPingOrchester orchestrates all the pings based on the next ping time of the cids
+// it sleeps until the soonest next ping of the cidSet, or until new CIDs are added to it.
+// The study is over once the set is empty and no more CIDs will be added to it
 func (pinger *CidPinger) runPingOrchester() {
 	defer pinger.orchersterWG.Done()
 	olog := log.WithField("pinger", "orchester")
 
-	minTimeT := time.NewTicker(minIterTime)
-
-	// ensure that the cidSet is not freshly created
-	for !pinger.cidS.isInit() {
-		select {
-		case <-minTimeT.C:
-			olog.Trace("cid set still not initialized")
-			minTimeT.Reset(minIterTime)
-		case <-pinger.orchersterCloseC:
-			break
-		}
-	}
+	wakeUpT := time.NewTimer(0)
+	defer wakeUpT.Stop()
 
 	// orchester loop
 	for {
+		// schedule all the CIDs whose next ping time has already come
+		for {
+			cidInfo, ok := pinger.cidS.nextReady(time.Now())
+			if !ok {
+				break
+			}
+			cidStr := cidInfo.CID.Hash().B58String()
+			cidInfo.IncreasePingCounter()
+			h, err := pinger.hostPool.GetBestHost(cidInfo)
+			switch err {
+			case nil:
+				olog.Debug(fmt.Sprintf("got host %d for next ping (%d) ongoing pings",
+					h.GetHostID(), h.GetOngoingCidPings()))
+			case p2p.ErrorRetrievingBestHost:
+				olog.Warn(err)
+			}
+
+			if cidInfo.IsFinished() {
+				pinger.cidS.removeCid(cidStr)
+				olog.Infof("finished pinging CID %s - pingend over %s (%d remaining)",
+					cidStr,
+					cidInfo.StudyDuration,
+					pinger.cidS.Len())
+			} else {
+				pinger.cidS.rescheduleCid(cidInfo)
+			}
+			// Add Cid to the host to have an effective WB
+			h.AddCidPing(cidInfo)
+			pinger.pingTaskC <- pingTask{h, cidInfo}
+		}
+
+		// check whether the adding is done before the set, so that the last CIDs can't be missed
+		addingDone := pinger.cidS.isDoneAdding()
+		nextPing, ok := pinger.cidS.NextValidTimeToPing()
+		if !wakeUpT.Stop() {
+			select {
+			case <-wakeUpT.C:
+			default:
+			}
+		}
+		if ok {
+			olog.Debugf("next ping in %s (%d CIDs)", time.Until(nextPing), pinger.cidS.Len())
+			wakeUpT.Reset(time.Until(nextPing))
+		} else if addingDone {
+			// if there are no more CIDs to track, and no more will come, we are done with the study
+			olog.Info("no more cids to ping, closing orcherster")
+			return
+		} else {
+			olog.Debug("waiting for new cids to ping")
+		}
+
 		select {
 		case <-pinger.ctx.Done():
 			olog.Info("shutdown was detected, closing Cid Ping Orchester")
@@ -147,66 +201,9 @@ func (pinger *CidPinger) runPingOrchester() {
 			olog.Info("shutdown was detected, closing Cid Ping Orchester")
 			return
 
-		default:
-			// loop over the list of CIDs, and check whether they need to be pinged or not
-			sortSet := false
-			if pinger.cidS.Next() {
-				cidInfo := pinger.cidS.Cid()
-				if cidInfo == nil {
-					sortSet = true
-					goto updateCidSet
-				}
-				cidStr := cidInfo.CID.Hash().B58String()
-				if !cidInfo.IsReadyForNextPing() {
-					if cidInfo.NextPing.IsZero() {
-						// as we organize the cids by ping time, "zero" time gets first
-						// breaking always the loop until all the Cids have been generated
-						// and published, thus, let the foor loop find a valid time
-						goto updateCidSet
-					} else {
-						olog.Debugf("not in time to ping %s next ping in %s", cidStr, cidInfo.NextPing.Sub(time.Now()))
-						sortSet = true
-						goto updateCidSet
-					}
-				}
-				cidInfo.IncreasePingCounter()
-				h, err := pinger.hostPool.GetBestHost(cidInfo)
-				switch err {
-				case nil:
-					olog.Debug(fmt.Sprintf("got host %d for next ping (%d) ongoing pings",
-						h.GetHostID(), h.GetOngoingCidPings()))
-				case p2p.ErrorRetrievingBestHost:
-					olog.Warn(err)
-				}
-
-				if cidInfo.IsFinished() {
-					pinger.cidS.removeCid(cidStr)
-					olog.Infof("finished pinging CID %s - pingend over %s (%d remaining)",
-						cidStr,
-						cidInfo.StudyDuration,
-						pinger.cidS.Len())
-				}
-				// Add Cid to the host to have an effective WB
-				h.AddCidPing(cidInfo)
-				pinger.pingTaskC <- pingTask{h, cidInfo}
-
-			} else {
-				sortSet = true
-			}
-
-		updateCidSet:
-			// if there are no more CIDs to track, we are done with the study
-			if pinger.cidS.Len() == 0 {
-				olog.Info("no more cids to ping, closing orcherster")
-				return
-			}
-			// check if we need to update the CidSet
-			if sortSet {
-				olog.Debugf("reorgananizing (%d) CIDs based on their next ping time", pinger.cidS.Len())
-				pinger.cidS.SortCidList()
-				<-minTimeT.C
-				minTimeT.Reset(minIterTime)
-			}
+		case <-wakeUpT.C:
+		case <-pinger.cidS.updated():
+			// a new CID might be the soonest one
 		}
 	}
 }
@@ -243,6 +240,8 @@ func (pinger *CidPinger) runPinger(pingerID int, closeC chan struct{}) {
 				pingCounter,
 				pingT.K,
 			)
+			cidFetchRes.CreatorState = pinger.creatorStatus.getState(pingT.Creator)
+			cidFetchRes.PlannedOffset = pingT.GetPlannedOffset(pingCounter)
 
 			pingCtx, cancel := context.WithTimeout(pinger.ctx, pinger.taskTimeout)
 			defer cancel()
@@ -254,17 +253,42 @@ func (pinger *CidPinger) runPinger(pingerID int, closeC chan struct{}) {
 				var prWithMAddrs bool = false
 
 				plog.Debug("finding providers...")
-				queryDuration, providers, err := pingT.host.FindXXProvidersOfCID(pingCtx, pingT.CidInfo, 1)
+				// look for the whole provider set of the CID, or for the configured target
+				// (never fewer than the providers we track, to see in which order they are returned)
+				creators := pingT.GetCreators()
+				traceCtx, stopTrace := pingT.host.TraceProviderLookup(pingCtx, pingT.CidInfo)
+				lookupStart := time.Now()
+				var queryDuration time.Duration
+				var providers []peer.AddrInfo
+				var err error
+				if pinger.provLookupTarget <= 0 {
+					queryDuration, providers, err = pingT.host.FindProvidersOfCID(traceCtx, pingT.CidInfo)
+				} else {
+					targetProviders := pinger.provLookupTarget
+					if targetProviders < len(creators) {
+						targetProviders = len(creators)
+					}
+					queryDuration, providers, err = pingT.host.FindXXProvidersOfCID(traceCtx, pingT.CidInfo, targetProviders)
+				}
+				provLookup := models.NewLookup(pingT.CID, pingCounter, models.ProvidersLookup, stopTrace())
 				cidFetchRes.FindProvDuration = queryDuration
 				if err != nil {
 					plog.Warnf("unable to lookup for provider of cid %s - %s",
 						cidStr, err.Error(),
 					)
+					cidFetchRes.RoutingError = err.Error()
 				}
+				cidFetchRes.AddProviders(creators, providers)
+				cidFetchRes.AddRoundProviders(models.NewRoundProviders(creators, providers, lookupStart, queryDuration, provLookup))
 				// iter through the providers to see if it matches with the host's peerID
-				for _, paddrs := range providers {
-					if paddrs.ID == pingT.Creator {
+				var creator *peer.AddrInfo
+				for i, paddrs := range providers {
+					if pingT.IsCreator(paddrs.ID) {
 						isRetrievable = true
+						// fetch from the main creator of the CID (i.e. the publisher host that provided it) if possible
+						if creator == nil || paddrs.ID == pingT.Creator {
+							creator = &providers[i]
+						}
 						if len(paddrs.Addrs) > 0 {
 							prWithMAddrs = true
 						}
@@ -273,14 +297,67 @@ func (pinger *CidPinger) runPinger(pingerID int, closeC chan struct{}) {
 				cidFetchRes.IsRetrievable = isRetrievable
 				cidFetchRes.PRWithMAddr = prWithMAddrs
 				plog.Debug("finished finding providers")
+
+				// retrieve the content from the discovered provider
+				if creator == nil {
+					cidFetchRes.FetchError = p2p.FetchErrorNoProvider
+					return
+				}
+				dialDuration, ttfb, sender, err := pingT.host.FetchCidFromProvider(pingCtx, *creator, pingT.CidInfo)
+				cidFetchRes.FetchDialDuration = dialDuration
+				cidFetchRes.FetchTTFB = ttfb
+				cidFetchRes.FetchedFrom = sender
+				cidFetchRes.FetchError = p2p.ParseConError(err)
+				cidFetchRes.IsFetchable = err == nil
+				if err != nil {
+					plog.Warnf("unable to fetch cid %s from provider %s - %s",
+						cidStr, creator.ID.String(), err.Error(),
+					)
+				}
+				plog.Debug("finished fetching the content")
 			}()
 
+			// look for the providers through the delegated routing endpoint as well (if any), as a separate routing system
+			var delegatedRes *models.CidFetchResults
+			if pingT.host.HasDelegatedRouting() {
+				delegatedRes = models.NewCidFetchResults(pingT.CID, pingT.PublishTime, pingCounter, pingT.K)
+				delegatedRes.RoutingSystem = models.DelegatedRouting
+				delegatedRes.CreatorState = cidFetchRes.CreatorState
+				delegatedRes.PlannedOffset = cidFetchRes.PlannedOffset
+				delegatedRes.TotalHops = -1
+				delegatedRes.HopsTreeDepth = -1
+				delegatedRes.MinHopsToClosest = -1
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					queryDuration, providers, err := pingT.host.FindProvidersDelegated(pingCtx, pingT.CidInfo)
+					delegatedRes.FindProvDuration = queryDuration
+					if err != nil {
+						plog.Warnf("unable to lookup for providers of cid %s through delegated routing - %s", cidStr, err.Error())
+						delegatedRes.RoutingError = err.Error()
+					}
+					delegatedRes.AddProviders(pingT.GetCreators(), providers)
+					delegatedRes.AddRoundProviders(models.NewRoundProviders(pingT.GetCreators(), providers, delegatedRes.StartTime, queryDuration, nil))
+					for _, paddrs := range providers {
+						if pingT.IsCreator(paddrs.ID) {
+							delegatedRes.IsRetrievable = true
+							if len(paddrs.Addrs) > 0 {
+								delegatedRes.PRWithMAddr = true
+							}
+						}
+					}
+					delegatedRes.FinishTime = time.Now()
+				}()
+			}
+
 			// recalculate the closest k peers to the content.
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
 				plog.Debug("getting closest peers")
-				queryDuration, closestPeers, lookupMetrics, err := pingT.host.GetClosestPeersToCid(pingCtx, pingT.CidInfo)
+				traceCtx, stopTrace := pingT.host.TraceLookup(pingCtx, pingT.CidInfo)
+				queryDuration, closestPeers, lookupMetrics, err := pingT.host.GetClosestPeersToCid(traceCtx, pingT.CidInfo)
+				cidFetchRes.AddLookup(models.NewLookup(pingT.CidInfo.CID, cidFetchRes.Round, models.ClosestPeersLookup, stopTrace()))
 				if err != nil {
 					plog.Warnf("unable to get the closest peers to cid %s - %s", cidStr, err.Error())
 				}
@@ -299,10 +376,39 @@ func (pinger *CidPinger) runPinger(pingerID int, closeC chan struct{}) {
 					cidFetchRes.AddClosestPeer(peer)
 				}
 				plog.Debug("finished getting closest peers")
+
+				// ask the current closest peers that aren't PR Holders whether they hold the PR as well
+				prHolders := make(map[peer.ID]struct{})
+				for _, prHolder := range pingT.CidInfo.GetPRHolders() {
+					prHolders[prHolder.ID] = struct{}{}
+				}
+				for _, closestPeer := range closestPeers {
+					if _, ok := prHolders[closestPeer]; ok {
+						continue
+					}
+					wg.Add(1)
+					go func(closestPeer peer.ID) {
+						defer wg.Done()
+						pInfo := models.NewPeerInfo(
+							closestPeer,
+							pingT.host.GetMAddrsOfPeer(closestPeer),
+							pingT.host.GetUserAgentOfPeer(closestPeer),
+						)
+						pingRes := pingT.host.PingPRHolderOnCid(pingCtx, pInfo.GetAddrInfo(), pingT.CidInfo)
+						pingRes.Round = pingCounter
+						cidFetchRes.AddClosestPeerPing(pInfo, pingRes)
+					}(closestPeer)
+				}
 			}()
 
 			// Ping in parallel each of the PRHolders
-			for _, remotePeer := range pingT.CidInfo.PRHolders {
+			// (PR Holders of all the provide rounds, as the republishes keep adding new ones,
+			// although only the ones of the initial publication are flagged as original holders)
+			originalHolders := make(map[peer.ID]struct{})
+			for _, prHolder := range pingT.CidInfo.GetOriginalPRHolders() {
+				originalHolders[prHolder.ID] = struct{}{}
+			}
+			for _, remotePeer := range pingT.CidInfo.GetPRHolders() {
 				wg.Add(1)
 				go func(remotePeer models.PeerInfo) {
 					defer wg.Done()
@@ -311,9 +417,38 @@ func (pinger *CidPinger) runPinger(pingerID int, closeC chan struct{}) {
 						remotePeer.GetAddrInfo(),
 						pingT.CidInfo)
 					pingRes.Round = pingCounter
+					_, pingRes.OriginalHolder = originalHolders[remotePeer.ID]
 					cidFetchRes.AddPRPingResults(pingRes)
 				}(*remotePeer)
 			}
+			// ping as well the PR Holders of the extra providers, asking them for the records of their provider
+			for _, provider := range pingT.CidInfo.GetExtraProviders() {
+				for _, remotePeer := range provider.Event.GetPRHolders() {
+					wg.Add(1)
+					go func(provider models.CidProvider, remotePeer models.PeerInfo) {
+						defer wg.Done()
+						pingRes := pingT.host.PingProviderHolderOnCid(
+							pingCtx,
+							remotePeer.GetAddrInfo(),
+							pingT.CidInfo,
+							provider.Provider)
+						pingRes.Round = pingCounter
+						cidFetchRes.AddProviderHolderPing(provider.Index, pingRes)
+					}(provider, *remotePeer)
+				}
+			}
+			// ask the holders of the IPNS record of the CID (if any) for it
+			if record := pingT.CidInfo.GetIpnsRecord(); record != nil {
+				for _, remotePeer := range record.GetHolders() {
+					wg.Add(1)
+					go func(remotePeer models.PeerInfo) {
+						defer wg.Done()
+						pingRes := pingT.host.PingIpnsHolder(pingCtx, remotePeer.GetAddrInfo(), record)
+						pingRes.Round = pingCounter
+						cidFetchRes.AddIpnsPingResult(pingRes)
+					}(*remotePeer)
+				}
+			}
 			plog.Debug("waiting tasks to finish")
 			wg.Wait()
 			plog.Debug("ping tasks just finished")
@@ -322,6 +457,10 @@ func (pinger *CidPinger) runPinger(pingerID int, closeC chan struct{}) {
 
 			cidFetchRes.FinishTime = time.Now()
 			pinger.dbCli.AddFetchResult(cidFetchRes)
+			if delegatedRes != nil {
+				pinger.dbCli.AddFetchResult(delegatedRes)
+			}
+			pinger.dbCli.AddClosestPeersOverlap(models.NewClosestPeersOverlap(cidFetchRes, pingT.CidInfo.GetOriginalPRHolders()))
 
 		case <-pinger.ctx.Done():
 			plog.Info("shutdown detected, closing pinger")
@@ -338,6 +477,11 @@ func (pinger *CidPinger) runPinger(pingerID int, closeC chan struct{}) {
 	}
 }
 
+// Done returns a channel that gets closed once the pinger has finished with all the CIDs
+func (pinger *CidPinger) Done() <-chan struct{} {
+	return pinger.doneC
+}
+
 func (pinger *CidPinger) GetHostWorkload() map[int]int {
 	return pinger.hostPool.GetHostWorkload()
 }
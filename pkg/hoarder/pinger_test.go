@@ -0,0 +1,38 @@
+package hoarder
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPingOrchesterWithoutCids(t *testing.T) {
+	pinger := &CidPinger{
+		ctx:              context.Background(),
+		orchersterWG:     new(sync.WaitGroup),
+		orchersterCloseC: make(chan struct{}, 1),
+		cidS:             newCidSet(),
+	}
+	pinger.orchersterWG.Add(1)
+	done := make(chan struct{})
+	go func() {
+		pinger.runPingOrchester()
+		close(done)
+	}()
+
+	// the orchester keeps waiting while CIDs might still come
+	select {
+	case <-done:
+		t.Fatal("the orchester finished before the tracker was done adding CIDs")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// every CID was discarded, the study is over without any ping
+	pinger.cidS.doneAdding()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the orchester didn't finish after the tracker was done adding CIDs")
+	}
+}
@@ -0,0 +1,114 @@
+package hoarder
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
+	"go.uber.org/atomic"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// pubScheduler hands out the publication slots to all the publisher workers following a single
+// PubSchedule, so that the workers don't publish in synchronized bursts. The slots work as a token
+// bucket: the ones that can't be handed out because all the workers are busy are kept (up to
+// bucketSize) and handed out as soon as the workers are free again
+type pubScheduler struct {
+	ctx        context.Context
+	schedule   *models.PubSchedule
+	rng        *rand.Rand
+	bucketSize int
+	slotC      chan struct{}
+
+	startTime    *atomic.Time
+	handedSlots  *atomic.Int64
+	droppedSlots *atomic.Int64
+}
+
+func newPubScheduler(ctx context.Context, schedule *models.PubSchedule, seed int64, bucketSize int) *pubScheduler {
+	if bucketSize < schedule.Burst {
+		bucketSize = schedule.Burst
+	}
+	return &pubScheduler{
+		ctx:          ctx,
+		schedule:     schedule,
+		rng:          rand.New(rand.NewSource(seed)),
+		bucketSize:   bucketSize,
+		slotC:        make(chan struct{}),
+		startTime:    atomic.NewTime(time.Time{}),
+		handedSlots:  atomic.NewInt64(0),
+		droppedSlots: atomic.NewInt64(0),
+	}
+}
+
+// run releases the slots of the schedule until the given channel is closed
+func (s *pubScheduler) run(doneC <-chan struct{}) {
+	slog := log.WithField("mod", "pub-scheduler")
+	slog.Infof("handing out publication slots with a %s schedule", s.schedule.Mode)
+	now := time.Now()
+	s.startTime.Store(now)
+
+	wait, nextSlots := s.schedule.Next(now, s.rng)
+	nextRelease := now.Add(wait)
+	releaseTimer := time.NewTimer(wait)
+	defer releaseTimer.Stop()
+
+	tokens := 0
+	for {
+		// only offer a slot to the workers when there are tokens in the bucket
+		var slotC chan struct{}
+		if tokens > 0 {
+			slotC = s.slotC
+		}
+		select {
+		case slotC <- struct{}{}:
+			tokens--
+			s.handedSlots.Inc()
+
+		case <-releaseTimer.C:
+			tokens += nextSlots
+			if tokens > s.bucketSize {
+				s.droppedSlots.Add(int64(tokens - s.bucketSize))
+				slog.Debugf("all the workers are busy, dropping %d slots", tokens-s.bucketSize)
+				tokens = s.bucketSize
+			}
+			// the next release is scheduled from the previous one, not from now, to keep the pace
+			wait, nextSlots = s.schedule.Next(nextRelease, s.rng)
+			nextRelease = nextRelease.Add(wait)
+			releaseTimer.Reset(time.Until(nextRelease))
+
+		case <-doneC:
+			slog.Info("publication finished, closing scheduler")
+			return
+
+		case <-s.ctx.Done():
+			slog.Info("shutdown detected, closing scheduler")
+			return
+		}
+	}
+}
+
+// slots returns the channel where the workers get their publication slots
+func (s *pubScheduler) slots() <-chan struct{} {
+	return s.slotC
+}
+
+// targetRate returns the publications per minute that the schedule aims for at the moment
+func (s *pubScheduler) targetRate() float64 {
+	return s.schedule.TargetRate(time.Now()) * 60
+}
+
+// achievedRate returns the publications per minute handed out to the workers since the scheduler started
+func (s *pubScheduler) achievedRate() float64 {
+	startTime := s.startTime.Load()
+	if startTime.IsZero() {
+		return 0
+	}
+	return float64(s.handedSlots.Load()) / time.Since(startTime).Minutes()
+}
+
+func (s *pubScheduler) getDroppedSlots() int64 {
+	return s.droppedSlots.Load()
+}
@@ -2,6 +2,7 @@ package hoarder
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
@@ -10,96 +11,194 @@ import (
 	"github.com/cortze/ipfs-cid-hoarder/pkg/p2p"
 	"go.uber.org/atomic"
 
-	"github.com/ipfs/go-cid"
 	pb "github.com/libp2p/go-libp2p-kad-dht/pb"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	mh "github.com/multiformats/go-multihash"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
+// ProvideRetryPolicy defines what to do with the initial publications that fail or that don't reach
+// enough PR Holders: retry them with an exponential backoff, and flag them as degraded or discard them
+// if they are still under-replicated after all the attempts
+type ProvideRetryPolicy struct {
+	Retries      int           // extra attempts after the first provide
+	Backoff      time.Duration // wait before the first retry, doubled on each of the following ones
+	MinPRHolders int           // successful ADD_PROVIDER messages to consider the CID provided
+	OnFailure    string        // models.DegradedCid or models.DiscardedCid
+}
+
+// isUnderReplicated returns true if the provide attempt failed or didn't reach enough PR Holders
+func (p ProvideRetryPolicy) isUnderReplicated(attempt *models.ProvideEvent) bool {
+	_, success, _ := attempt.Results.GetSummary()
+	return attempt.Error != "" || success < p.MinPRHolders
+}
+
+// ongoingProvide links the provide event with the CID it belongs to, as the CIDs
+// aren't added to the cidSet until their publication is complete
+type ongoingProvide struct {
+	cidInfo *models.CidInfo
+	event   *models.ProvideEvent
+}
+
+// ongoingProvideKey identifies the provide of a CID by one of the publisher hosts, as several hosts can be
+// providing the same CID at the same time. The CIDs are keyed by their multihash, as it's the key of the
+// ADD_PROVIDER messages
+func ongoingProvideKey(hostID int, hash mh.Multihash) string {
+	return fmt.Sprintf("%d/%s", hostID, hash.B58String())
+}
+
+// prHolderHost is the part of the publisher hosts needed to track the PR Holders of their provides
+type prHolderHost interface {
+	GetHostID() int
+	ID() peer.ID
+	GetMAddrsOfPeer(p peer.ID) []ma.Multiaddr
+	GetUserAgentOfPeer(p peer.ID) string
+}
+
+// ongoingIpnsKey identifies the PutValue of an IPNS record by one of the publisher hosts
+// (the keys of the records start with /ipns/, so they can't clash with the provides)
+func ongoingIpnsKey(h *p2p.DHTHost, key string) string {
+	return fmt.Sprintf("%d%s", h.GetHostID(), key)
+}
+
+// PublisherOptions gathers the parameters of the study that shape the publications of the CidPublisher
+type PublisherOptions struct {
+	K                 int
+	Workers           int
+	Hosts             int // identities of the host pool that provide the CIDs
+	ProvideBatchSize  int // CIDs provided at once with the fullrt ProvideMany (1 for the rest of operations)
+	ProvidersPerCid   int // publisher hosts that provide each CID (the first one is its creator)
+	ReqInterval       time.Duration
+	PubInterval       time.Duration
+	CidPingTime       time.Duration
+	RepublishInterval time.Duration // 0 to provide each CID only once
+	ProviderStagger   time.Duration // delay between the provides of each of the providers of a CID
+	RetryPolicy       ProvideRetryPolicy
+	OfflineWindows    []models.OfflineWindow // periods of the study in which the hosts are taken offline
+	IpnsValidity      time.Duration          // validity of the IPNS record published for each CID (0 to skip them)
+	PingSchedule      *models.PingSchedule   // offsets since the publication of each ping round
+}
+
 type CidPublisher struct {
 	ctx   context.Context
 	appWG *sync.WaitGroup
+	// the content keeps being served until the pinger is done with the study
+	studyDoneC <-chan struct{}
 
-	host         *p2p.DHTHost
+	// each CID is provided by one of the identities of the pool, which becomes its creator
+	hostPool     *p2p.HostPool
+	hosts        []*p2p.DHTHost
+	hostCounter  *atomic.Int64
 	dhtProvide   p2p.ProvideOption
 	DBCli        *db.DBClient
 	cidGenerator *CidGenerator
 
-	//receive message from listen for add provider message function
-	MsgNot      *p2p.MsgNotifier
-	K           int
-	Workers     int
-	ReqInterval time.Duration
-	PubInterval time.Duration
-	CidPingTime time.Duration
+	PublisherOptions
 
 	// main set of Cids that will keep track of them over the run
 	cidSet         *cidSet
+	scheduler      *pubScheduler // hands out the publication slots to the workers
+	creatorStatus  *creatorStatus
 	metrics        *publisherMetrics
 	generationDone *atomic.Bool
+	retriesWG      sync.WaitGroup // publications waiting to be retried
+	providersWG    sync.WaitGroup // extra providers waiting to provide their CIDs
 }
 
 func NewCidPublisher(
 	ctx context.Context,
 	appWG *sync.WaitGroup,
+	studyDoneC <-chan struct{},
 	hostOpts p2p.DHTHostOptions,
 	db *db.DBClient,
 	generator *CidGenerator,
 	cidSet *cidSet,
-	k, workers int,
-	reqInterval, pubInterval, cidPingTime time.Duration,
+	scheduler *pubScheduler,
+	creatorStatus *creatorStatus,
+	opts PublisherOptions,
 ) (*CidPublisher, error) {
 
 	log.WithField("mod", "publisher").Info("initializing...")
-	h, err := p2p.NewDHTHost( // host is already bootstrapped
+	hostPool, err := p2p.NewHostPool( // hosts are already bootstrapped
 		ctx,
+		opts.Hosts,
 		hostOpts,
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "publisher:")
 	}
+	for _, h := range hostPool.GetHosts() {
+		creatorStatus.setOnline(h.ID(), true)
+	}
 	log.WithField("mod", "publisher").Info("initialized...")
 	return &CidPublisher{
-		ctx:            ctx,
-		appWG:          appWG,
-		host:           h,
-		dhtProvide:     hostOpts.ProvOp,
-		DBCli:          db,
-		MsgNot:         h.GetMsgNotifier(),
-		cidGenerator:   generator,
-		K:              k,
-		ReqInterval:    reqInterval,
-		PubInterval:    pubInterval,
-		CidPingTime:    cidPingTime,
-		Workers:        workers,
-		cidSet:         cidSet,
-		metrics:        newPublisherMetrics(string(hostOpts.ProvOp)),
-		generationDone: atomic.NewBool(false),
+		ctx:              ctx,
+		appWG:            appWG,
+		studyDoneC:       studyDoneC,
+		hostPool:         hostPool,
+		hosts:            hostPool.GetHosts(),
+		hostCounter:      atomic.NewInt64(0),
+		dhtProvide:       hostOpts.ProvOp,
+		DBCli:            db,
+		cidGenerator:     generator,
+		PublisherOptions: opts,
+		cidSet:           cidSet,
+		scheduler:        scheduler,
+		creatorStatus:    creatorStatus,
+		metrics:          newPublisherMetrics(string(hostOpts.ProvOp)),
+		generationDone:   atomic.NewBool(false),
 	}, nil
 }
 
 func (publisher *CidPublisher) Run() {
 	defer publisher.appWG.Done()
-	// launch the PRholder reading routine
-	msgNotChannel := publisher.MsgNot.GetNotifierChan()
+	studyStart := time.Now()
 
 	// control variables
 	var publisherWG sync.WaitGroup
+	var republisherWG sync.WaitGroup
 	var msgNotWG sync.WaitGroup
-	var firstCidFetchRes sync.Map
+	var ongoingProvides sync.Map                             // ongoingProvide of each CID being provided
 	genDoneCs := make([]chan struct{}, 0, publisher.Workers) // one per each publisher instance
-	publicationDoneC := make(chan struct{})                  // there is one singel msg-not-reader
+	publicationDoneC := make(chan struct{})                  // closed to stop the msg-not-readers
+	initialPubDoneC := make(chan struct{})                   // closed once all the CIDs were published once
+	schedulerDoneC := make(chan struct{})
 
 	plog := log.WithField("mod", "publisher")
-	// IPFS DHT Message Notification Listener
-	msgNotWG.Add(1)
-	go publisher.addProviderMsgListener(
-		&msgNotWG,
-		publicationDoneC,
-		&firstCidFetchRes,
-		msgNotChannel,
-	)
+	// IPFS DHT Message Notification Listener (one per publisher host)
+	for _, h := range publisher.hosts {
+		msgNotWG.Add(1)
+		go publisher.addProviderMsgListener(
+			&msgNotWG,
+			publicationDoneC,
+			&ongoingProvides,
+			h,
+		)
+	}
+
+	if publisher.RepublishInterval > 0 {
+		republisherWG.Add(1)
+		go publisher.republishingProcess(
+			&republisherWG,
+			initialPubDoneC,
+			&ongoingProvides,
+		)
+	}
+
+	if len(publisher.OfflineWindows) > 0 {
+		go publisher.availabilityProcess(studyStart)
+	}
+
+	// the accelerated client can't provide anything until it knows the whole network
+	for _, h := range publisher.hosts {
+		if err := h.WaitForFullRT(publisher.ctx); err != nil {
+			plog.Error(err)
+		}
+	}
 
+	go publisher.scheduler.run(schedulerDoneC)
 	cidPubC, genWG := publisher.cidGenerator.Run()
 	for publisherCounter := 0; publisherCounter < publisher.Workers; publisherCounter++ {
 		publisherWG.Add(1)
@@ -109,7 +208,7 @@ func (publisher *CidPublisher) Run() {
 			generationDoneC,
 			publisherCounter,
 			cidPubC,
-			&firstCidFetchRes,
+			&ongoingProvides,
 		)
 		genDoneCs = append(genDoneCs, generationDoneC)
 	}
@@ -124,103 +223,149 @@ func (publisher *CidPublisher) Run() {
 	}
 
 	publisherWG.Wait()
+	close(schedulerDoneC)
+	publisher.retriesWG.Wait()
+	publisher.providersWG.Wait()
 	plog.Info("publication process finished successfully")
-	publicationDoneC <- struct{}{}
+	// all the published CIDs are already in the set, the pinger can finish once they are done
+	publisher.cidSet.doneAdding()
+	close(initialPubDoneC)
+
+	// the republishes keep sending ADD_PROVIDER messages until the CIDs are no longer tracked
+	republisherWG.Wait()
+	close(publicationDoneC)
 
 	msgNotWG.Wait()
 	plog.Info("msg notification channel finished successfully")
 
-	publisher.host.Close()
+	// keep the hosts online serving the content over bitswap until the CIDs are no longer pinged
+	plog.Info("serving the published content until the end of the study")
+	select {
+	case <-publisher.studyDoneC:
+	case <-publisher.ctx.Done():
+	}
+	publisher.hostPool.Close()
 	plog.Info("publisher successfully closed")
-	close(publicationDoneC)
 }
 
-// addProviderMsgListener listens the Notchannel for ADD_PROVIDER messages for the provided CIDs
-// from each of the messages received, it composes/adds a new PR holder to the CID
+// availabilityProcess takes the publisher hosts offline and back online following the offline windows,
+// which are relative to the start of the study
+func (publisher *CidPublisher) availabilityProcess(studyStart time.Time) {
+	alog := log.WithField("mod", "creator-availability")
+	for _, window := range publisher.OfflineWindows {
+		transitions := []struct {
+			offset time.Duration
+			online bool
+		}{{window.Start, false}, {window.End, true}}
+		for _, transition := range transitions {
+			select {
+			case <-time.After(time.Until(studyStart.Add(transition.offset))):
+			case <-publisher.studyDoneC:
+				return
+			case <-publisher.ctx.Done():
+				return
+			}
+			for _, h := range publisher.hosts {
+				h.SetOnline(transition.online)
+				publisher.creatorStatus.setOnline(h.ID(), transition.online)
+			}
+			if transition.online {
+				alog.Infof("offline window %s-%s finished, publisher hosts back online", window.Start, window.End)
+			} else {
+				alog.Infof("offline window %s-%s started, publisher hosts offline", window.Start, window.End)
+			}
+		}
+	}
+}
+
+// nextHosts returns the given number of consecutive publisher hosts that will provide the next CIDs,
+// spreading them in a round-robin fashion among all the identities of the pool
+func (publisher *CidPublisher) nextHosts(n int) []*p2p.DHTHost {
+	first := publisher.hostCounter.Add(int64(n)) - int64(n)
+	hosts := make([]*p2p.DHTHost, 0, n)
+	for i := int64(0); i < int64(n); i++ {
+		hosts = append(hosts, publisher.hosts[(first+i)%int64(len(publisher.hosts))])
+	}
+	return hosts
+}
+
+// creatorHost returns the publisher host that created the given CID, which is the one
+// that provides it in the retries and republishes
+func (publisher *CidPublisher) creatorHost(cidInfo *models.CidInfo) *p2p.DHTHost {
+	h, ok := publisher.hostPool.GetHost(cidInfo.Creator)
+	if !ok {
+		return publisher.hosts[0]
+	}
+	return h
+}
+
+// addProviderMsgListener listens the Notchannel of a host for ADD_PROVIDER messages for the provided CIDs
+// from each of the messages received, it composes/adds a new PR holder to the CID and to the ongoing provide event
 // finally, it aggregates all the PingRound info of the publication as the first PingRound (0)
+// The PUT_VALUE messages of the IPNS records are tracked the same way as holders of the ongoing records
 func (publisher *CidPublisher) addProviderMsgListener(
 	msgNotWg *sync.WaitGroup,
 	publicationDoneC chan struct{},
-	firstCidFetchRes *sync.Map,
-	msgNotChannel chan *p2p.MsgNotification) {
+	ongoingProvides *sync.Map,
+	h *p2p.DHTHost) {
 	defer func() {
 		// notify that the msg listener has been closed
 		msgNotWg.Done()
 	}()
-	mlog := log.WithField("service", "msg-listener")
+	mlog := log.WithFields(log.Fields{
+		"service": "msg-listener",
+		"host-id": h.GetHostID(),
+	})
+	msgNotChannel := h.GetMsgNotifier().GetNotifierChan()
 	for {
 		select {
 		// this receives a message from SendMessage in messages.go after the DHT.Provide operation
 		// is called from the PUT_PROVIDER method.
 		case msgNot := <-msgNotChannel:
 			// check the msg type
-			castedCid, err := cid.Cast(msgNot.Msg.GetKey())
-			if err != nil {
-				mlog.Errorf("unable to cast msg key into cid. %s", err.Error())
-			}
 			switch msgNot.Msg.Type {
 			case pb.Message_ADD_PROVIDER:
-				var active bool
-				var connError string
-
-				if msgNot.Error != nil {
-					//TODO: parse the errors in a better way
-					connError = p2p.ParseConError(msgNot.Error)
-					mlog.Debugf("Failed putting PR for CID %s of PRHolder %s - error %s",
-						castedCid.Hash().B58String(), msgNot.RemotePeer.String(), msgNot.Error.Error(),
-					)
-				} else {
-					// assume that if the peer replies successfully to the ADD_PROVIDER messages,
-					// the remote peer keeps the info (We are assuming also this for the Hydras)
-					active = true
-					connError = p2p.NoConnError
-					mlog.Debugf("Successfull PRHolder for CID %s of PRHolder %s", castedCid.Hash().B58String(), msgNot.RemotePeer.String())
-				}
+				trackAddProvider(mlog, h, ongoingProvides, msgNot)
 
-				// Read the CidInfo from the local Sync.Map struct
-				cidInfo, ok := publisher.cidSet.getCid(castedCid.Hash().B58String())
+			case pb.Message_PUT_VALUE:
+				val, ok := ongoingProvides.Load(ongoingIpnsKey(h, string(msgNot.Msg.GetKey())))
 				if !ok {
-					mlog.Panic("unable to find CidInfo on CidSet for Cid ", castedCid.Hash().B58String())
+					mlog.Debug("no ongoing ipns record for the key, PUT_VALUE arrived too late")
+					continue
 				}
-
-				// add the ping result
-				val, ok := firstCidFetchRes.Load(castedCid.Hash().B58String())
-				cidFetRes := val.(*models.CidFetchResults)
-				if !ok {
-					mlog.Panicf("CidFetcher not found for cid %s", castedCid.Hash().B58String())
+				record := val.(*models.IpnsRecord)
+				active := msgNot.Error == nil
+				connError := p2p.NoConnError
+				if !active {
+					connError = p2p.ParseConError(msgNot.Error)
 				}
-
-				// save the ping result into the FetchRes
-				cidFetRes.AddPRPingResults(models.NewPRPingResults(
-					castedCid,
-					msgNot.RemotePeer,
-					0, // round is 0 since is the ADD_PROVIDE result
-					cidFetRes.GetPublicationTime(),
-					msgNot.QueryTime,
-					msgNot.QueryDuration,
-					active,
-					false,
-					false,
-					connError),
+				record.AddHolder(
+					models.NewPeerInfo(
+						msgNot.RemotePeer,
+						h.GetMAddrsOfPeer(msgNot.RemotePeer),
+						h.GetUserAgentOfPeer(msgNot.RemotePeer),
+					),
+					models.NewPRPingResults(
+						record.Cid,
+						msgNot.RemotePeer,
+						0,
+						record.PublishTime,
+						msgNot.QueryTime,
+						msgNot.QueryDuration,
+						active,
+						false,
+						false,
+						connError),
 				)
-
-				// Generate the new PeerInfo struct for the new PRHolder
-				prHolderInfo := models.NewPeerInfo(
-					msgNot.RemotePeer,
-					publisher.host.GetMAddrsOfPeer(msgNot.RemotePeer),
-					publisher.host.GetUserAgentOfPeer(msgNot.RemotePeer),
-				)
-
-				// add all the PRHolder info to the CidInfo
-				cidInfo.AddPRHolder(prHolderInfo)
-
-				if cidFetRes.IsDone() {
-					// notify the publisher that the CID is ready
-					cidFetRes.DoneC <- struct{}{}
+				if record.IsDone() {
+					select {
+					case record.DoneC <- struct{}{}:
+					default:
+					}
 				}
 
 			default:
-				// the message that we tracked is not ADD_PROVIDER, skipping
+				// the message that we tracked is not ADD_PROVIDER nor PUT_VALUE, skipping
 			}
 
 		case <-publisher.ctx.Done():
@@ -236,6 +381,84 @@ func (publisher *CidPublisher) addProviderMsgListener(
 	}
 }
 
+// trackAddProvider adds the peer that got the ADD_PROVIDER message as PR Holder of the ongoing provide
+// the message belongs to. The key of the message is the multihash of the CID, not the CID itself
+func trackAddProvider(mlog *log.Entry, h prHolderHost, ongoingProvides *sync.Map, msgNot *p2p.MsgNotification) {
+	hash, err := mh.Cast(msgNot.Msg.GetKey())
+	if err != nil {
+		mlog.Errorf("unable to cast msg key into multihash. %s", err.Error())
+		return
+	}
+	var active bool
+	var connError string
+
+	if msgNot.Error != nil {
+		//TODO: parse the errors in a better way
+		connError = p2p.ParseConError(msgNot.Error)
+		mlog.Debugf("Failed putting PR for CID %s of PRHolder %s - error %s",
+			hash.B58String(), msgNot.RemotePeer.String(), msgNot.Error.Error(),
+		)
+	} else {
+		// assume that if the peer replies successfully to the ADD_PROVIDER messages,
+		// the remote peer keeps the info (We are assuming also this for the Hydras)
+		active = true
+		connError = p2p.NoConnError
+		mlog.Debugf("Successfull PRHolder for CID %s of PRHolder %s", hash.B58String(), msgNot.RemotePeer.String())
+	}
+
+	// Read the provide event that the message belongs to
+	val, ok := ongoingProvides.Load(ongoingProvideKey(h.GetHostID(), hash))
+	if !ok {
+		mlog.Debugf("no ongoing provide for cid %s, ADD_PROVIDER arrived too late", hash.B58String())
+		return
+	}
+	provide := val.(*ongoingProvide)
+	provideEvent := provide.event
+	cidInfo := provide.cidInfo
+	cidFetRes := provideEvent.Results
+
+	// save the ping result into the FetchRes
+	cidFetRes.AddPRPingResults(models.NewPRPingResults(
+		cidInfo.CID,
+		msgNot.RemotePeer,
+		cidFetRes.Round, // 0 for the ADD_PROVIDE result of the initial publication
+		cidFetRes.GetPublicationTime(),
+		msgNot.QueryTime,
+		msgNot.QueryDuration,
+		active,
+		false,
+		false,
+		connError),
+	)
+
+	// Generate the new PeerInfo struct for the new PRHolder
+	prHolderInfo := models.NewPeerInfo(
+		msgNot.RemotePeer,
+		h.GetMAddrsOfPeer(msgNot.RemotePeer),
+		h.GetUserAgentOfPeer(msgNot.RemotePeer),
+	)
+
+	// add the PRHolder info to the provide event, and to the CidInfo only the new ones of the republishes
+	// (the PR Holders of the initial publication are taken from the accepted attempt once it finishes)
+	switch {
+	case h.ID() != cidInfo.Creator:
+		// the PR Holders of the rest of providers are only tracked in their own provide
+		provideEvent.AddPRHolder(prHolderInfo, false)
+	case provideEvent.Round == 0:
+		provideEvent.AddPRHolder(prHolderInfo, true)
+	default:
+		provideEvent.AddPRHolder(prHolderInfo, cidInfo.AddNewPRHolder(prHolderInfo))
+	}
+
+	if cidFetRes.IsDone() {
+		// notify the publisher that the CID is ready (the fullrt provide can reach more than K peers)
+		select {
+		case cidFetRes.DoneC <- struct{}{}:
+		default:
+		}
+	}
+}
+
 // publisherService is a service that generates random CID from the generator
 // and publishes them to the IPFS network based on the specified configuration
 // the publisher also tracks the provide operation instrumenting it, persisting the metadata
@@ -244,8 +467,8 @@ func (publisher *CidPublisher) publishingProcess(
 	publisherWG *sync.WaitGroup,
 	generationDoneC chan struct{},
 	publisherID int,
-	cidChannel chan *cid.Cid,
-	cidFetchRes *sync.Map) {
+	cidChannel chan *GeneratedCid,
+	ongoingProvides *sync.Map) {
 
 	defer publisherWG.Done()
 
@@ -254,7 +477,6 @@ func (publisher *CidPublisher) publishingProcess(
 	plog.Debugf("publisher ready")
 
 	generationDone := false
-	cidPubTicker := time.NewTicker(publisher.PubInterval)
 	minIterTicker := time.NewTicker(minIterTime)
 
 	for {
@@ -264,73 +486,81 @@ func (publisher *CidPublisher) publishingProcess(
 			return
 		}
 		select {
-		//this channel receives the CID from the CID generator go routine
-		case <-cidPubTicker.C:
-			cidPubTicker.Reset(publisher.PubInterval)
-
-			nextCid := <-cidChannel
-			cidStr := nextCid.Hash().B58String()
-			plog.Debugf("new cid to publish %s", cidStr)
-
-			pCtx, cancel := context.WithTimeout(publisher.ctx, publisher.PubInterval-1*time.Second)
-
-			// generate the new CidInfo cause a new CID was just received
-			cidInfo := models.NewCidInfo(
-				*nextCid,
-				publisher.K,
-				publisher.ReqInterval,
-				publisher.CidPingTime,
-				string(publisher.dhtProvide),
-				publisher.host.ID(),
-			)
-
-			// track the new Cid into the cidSet
-			publisher.cidSet.addCid(cidInfo)
+		// the scheduler hands out the publication slots among all the workers
+		case <-publisher.scheduler.slots():
+			// the fullrt provide takes as many waiting CIDs as the batch allows (at least one)
+			nextCids := []*GeneratedCid{<-cidChannel}
+		batchLoop:
+			for len(nextCids) < publisher.ProvideBatchSize {
+				select {
+				case nextCid := <-cidChannel:
+					nextCids = append(nextCids, nextCid)
+				default:
+					break batchLoop
+				}
+			}
+
+			// all the CIDs of the batch are provided by the same hosts, the first one being their creator
+			providerHosts := publisher.nextHosts(publisher.ProvidersPerCid)
+			h := providerHosts[0]
+			cidInfos := make([]*models.CidInfo, 0, len(nextCids))
+			provideEvents := make([]*models.ProvideEvent, 0, len(nextCids))
 			pubTime := time.Now()
-			// compose the fetchRes of the publication phase
-			fetchRes := models.NewCidFetchResults(*nextCid, pubTime, 0, publisher.K)
-			cidFetchRes.Store(cidStr, fetchRes)
+			for _, nextCid := range nextCids {
+				plog.Debugf("new cid to publish %s", nextCid.CID.Hash().B58String())
+
+				// generate the new CidInfo cause a new CID was just received
+				cidInfo := models.NewCidInfo(
+					nextCid.CID,
+					publisher.K,
+					publisher.ReqInterval,
+					publisher.CidPingTime,
+					string(publisher.dhtProvide),
+					h.ID(),
+				)
+				cidInfo.AddCreatorHost(h.GetHostID())
+				cidInfo.AddPingSchedule(publisher.PingSchedule)
+				for idx, providerHost := range providerHosts {
+					cidInfo.AddCreator(providerHost.ID())
+					cidInfo.AddProvider(&models.CidProvider{
+						Index:    idx,
+						Provider: providerHost.ID(),
+						HostID:   providerHost.GetHostID(),
+						Delay:    time.Duration(idx) * publisher.ProviderStagger,
+					})
+				}
+				cidInfo.AddSource(nextCid.Source)
+				cidInfo.AddGeneration(nextCid.GenerationID, nextCid.Index)
+				cidInfo.AddTargetRegion(nextCid.TargetRegion)
+				cidInfo.AddDagRoot(nextCid.DagRoot)
+				cidInfo.AddContentSize(nextCid.ContentSize)
 
-			reqTime, lookupMetrics, err := publisher.host.ProvideCid(pCtx, cidInfo)
-			if err != nil {
-				plog.Errorf("unable to Provide content. %s", err.Error())
+				// compose the provide event (and its fetchRes) of the publication phase
+				provideEvent := models.NewProvideEvent(nextCid.CID, 0, 0, pubTime, publisher.K)
+				cidInfos = append(cidInfos, cidInfo)
+				provideEvents = append(provideEvents, provideEvent)
 			}
-			if lookupMetrics != nil {
-				fetchRes.TotalHops = lookupMetrics.GetTotalHops()
-				fetchRes.HopsTreeDepth = lookupMetrics.GetTreeDepth()
-				fetchRes.MinHopsToClosest = lookupMetrics.GetMinHopsForPeerSet(lookupMetrics.GetClosestPeers())
+
+			if publisher.dhtProvide == p2p.FullRTProvide {
+				publisher.provideBatch(plog, h, cidInfos, provideEvents, ongoingProvides)
 			} else {
-				fetchRes.TotalHops = -1
-				fetchRes.HopsTreeDepth = -1
-				fetchRes.MinHopsToClosest = -1
+				publisher.provide(plog, h, cidInfos[0], provideEvents[0], ongoingProvides)
 			}
 
-			// Make sure we have received all the messages from the publication
-			// but do not wait forever
-			select {
-			case <-fetchRes.DoneC:
-				plog.Trace("finished publishing cid")
-			case <-pCtx.Done():
-				plog.Warnf("timeout publishing CID reached")
-				// give 500 ms extra to see if we receive any not from the msg-notifier
-				time.Sleep(500 * time.Millisecond)
+			for i, cidInfo := range cidInfos {
+				attempts := []*models.ProvideEvent{provideEvents[i]}
+				if publisher.RetryPolicy.Retries > 0 && publisher.RetryPolicy.isUnderReplicated(provideEvents[i]) {
+					// don't hold the worker while waiting for the backoff
+					publisher.retriesWG.Add(1)
+					go func(cidInfo *models.CidInfo) {
+						defer publisher.retriesWG.Done()
+						attempts = publisher.retryProvide(plog, cidInfo, attempts, ongoingProvides)
+						publisher.finishPublication(plog, cidInfo, attempts, ongoingProvides)
+					}(cidInfo)
+					continue
+				}
+				publisher.finishPublication(plog, cidInfo, attempts, ongoingProvides)
 			}
-			cancel()
-
-			// update the info of the Cid After its publication
-			cidInfo.AddPublicationTime(pubTime)
-			cidInfo.AddProvideTime(reqTime)
-			cidInfo.AddPRFetchResults(fetchRes)
-
-			// add to the metrics
-			publisher.metrics.addCid(string(publisher.dhtProvide))
-
-			// the Cid has already being published, save it into the DB
-			publisher.DBCli.AddCidInfo(cidInfo)
-			publisher.DBCli.AddFetchResult(fetchRes)
-
-			// print summary of the publication (round 0)
-			publisher.printSummary(plog, cidInfo, 0)
 
 		case <-publisher.ctx.Done():
 			plog.Debugf("shutdown detected, closing publisher")
@@ -347,6 +577,399 @@ func (publisher *CidPublisher) publishingProcess(
 	}
 }
 
+// retryProvide provides the CID again, with an exponential backoff, until it reaches enough PR Holders
+// or it runs out of attempts, returning all the attempts of the initial publication
+func (publisher *CidPublisher) retryProvide(
+	plog *log.Entry,
+	cidInfo *models.CidInfo,
+	attempts []*models.ProvideEvent,
+	ongoingProvides *sync.Map) []*models.ProvideEvent {
+
+	backoff := publisher.RetryPolicy.Backoff
+	for retry := 0; retry < publisher.RetryPolicy.Retries; retry++ {
+		lastAttempt := attempts[len(attempts)-1]
+		if !publisher.RetryPolicy.isUnderReplicated(lastAttempt) {
+			break
+		}
+		_, success, _ := lastAttempt.Results.GetSummary()
+		plog.Infof("cid %s under-replicated on attempt %d (%d successfull PRHolders), retrying in %s",
+			cidInfo.CID.Hash().B58String(), lastAttempt.Attempt, success, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-publisher.ctx.Done():
+			return attempts
+		}
+		backoff *= 2
+
+		attempt := lastAttempt.Retry(time.Now(), publisher.K)
+		publisher.provide(plog, publisher.creatorHost(cidInfo), cidInfo, attempt, ongoingProvides)
+		attempts = append(attempts, attempt)
+	}
+	return attempts
+}
+
+// finishPublication completes the CidInfo with the last attempt of its initial publication,
+// persisting it with all its attempts. The CID is tracked by the pinger unless it has to be discarded
+func (publisher *CidPublisher) finishPublication(
+	plog *log.Entry,
+	cidInfo *models.CidInfo,
+	attempts []*models.ProvideEvent,
+	ongoingProvides *sync.Map) {
+
+	provideEvent := attempts[len(attempts)-1]
+	fetchRes := provideEvent.Results
+	status := models.ProvidedCid
+	if publisher.RetryPolicy.isUnderReplicated(provideEvent) {
+		status = publisher.RetryPolicy.OnFailure
+	}
+
+	// update the info of the Cid After its publication
+	// the accepted attempt is the single source of the PR Holders (and K) of the CID
+	for _, prHolder := range provideEvent.GetPRHolders() {
+		cidInfo.AddPRHolder(prHolder)
+	}
+	cidInfo.AddProvideStatus(status, len(attempts))
+	cidInfo.AddProvideEvent(provideEvent)
+	cidInfo.AddPublicationTime(provideEvent.ProvideTime)
+	cidInfo.AddProvideTime(provideEvent.ProvideDuration)
+	cidInfo.AddPRFetchResults(fetchRes)
+
+	// publish the IPNS record of the Cid before the pinger starts tracking it
+	creator := publisher.creatorHost(cidInfo)
+	if status != models.DiscardedCid && publisher.IpnsValidity > 0 {
+		publisher.publishIpns(plog, creator, cidInfo, ongoingProvides)
+	}
+	// provide it as well through the delegated routing endpoint, to compare both routing systems
+	var delegatedRes *models.CidFetchResults
+	if status != models.DiscardedCid && creator.HasDelegatedRouting() {
+		delegatedRes = publisher.provideDelegated(plog, creator, cidInfo)
+	}
+
+	// track the new Cid into the cidSet
+	if status != models.DiscardedCid {
+		publisher.cidSet.addCid(cidInfo)
+	}
+
+	// add to the metrics
+	publisher.metrics.addCid(string(publisher.dhtProvide))
+
+	// the Cid has already being published, save it into the DB
+	publisher.DBCli.AddCidInfo(cidInfo)
+	publisher.DBCli.AddFetchResult(fetchRes)
+	publisher.DBCli.AddProvideEvent(cidInfo, provideEvent)
+	publisher.DBCli.AddProvideAttempts(attempts)
+	publisher.DBCli.AddClosestPeersOverlap(models.NewClosestPeersOverlap(fetchRes, cidInfo.GetOriginalPRHolders()))
+	if record := cidInfo.GetIpnsRecord(); record != nil {
+		publisher.DBCli.AddIpnsRecord(record)
+	}
+	if delegatedRes != nil {
+		publisher.DBCli.AddFetchResult(delegatedRes)
+	}
+
+	// the creator is the first provider, the rest provide the CID after their delay
+	cidInfo.AddProviderEvent(0, provideEvent)
+	publisher.DBCli.AddCidProvider(cidInfo.Providers[0])
+	if status != models.DiscardedCid {
+		for _, provider := range cidInfo.Providers[1:] {
+			publisher.providersWG.Add(1)
+			go publisher.provideFromProvider(plog, cidInfo, provider, ongoingProvides)
+		}
+	}
+
+	// print summary of the publication (round 0)
+	publisher.printSummary(plog, cidInfo, 0)
+	if status != models.ProvidedCid {
+		plog.Warnf("Cid %s %s after %d attempts", cidInfo.CID.Hash().B58String(), status, len(attempts))
+	}
+}
+
+// publishIpns publishes a new IPNS record pointing to the CID through the DHT PutValue, waiting until all
+// the PUT_VALUE messages of the record have been tracked by the msg listener (but not longer than the PubInterval)
+func (publisher *CidPublisher) publishIpns(
+	plog *log.Entry,
+	h *p2p.DHTHost,
+	cidInfo *models.CidInfo,
+	ongoingProvides *sync.Map) {
+
+	record, err := p2p.NewIpnsRecord(cidInfo.CID, publisher.IpnsValidity, publisher.K)
+	if err != nil {
+		plog.Errorf("unable to create the ipns record of cid %s - %s", cidInfo.CID.Hash().B58String(), err.Error())
+		return
+	}
+	record.PublishTime = time.Now()
+	ipnsKey := ongoingIpnsKey(h, record.Key)
+	ongoingProvides.Store(ipnsKey, record)
+	defer ongoingProvides.Delete(ipnsKey)
+
+	pCtx, cancel := context.WithTimeout(publisher.ctx, publisher.PubInterval-1*time.Second)
+	defer cancel()
+
+	record.PublishDuration, err = h.PutIpnsRecord(pCtx, record)
+	if err != nil {
+		plog.Errorf("unable to put the ipns record of cid %s - %s", cidInfo.CID.Hash().B58String(), err.Error())
+		record.Error = err.Error()
+	}
+	select {
+	case <-record.DoneC:
+		plog.Trace("finished publishing ipns record")
+	case <-pCtx.Done():
+		plog.Warnf("timeout publishing ipns record reached")
+	case <-time.After(500 * time.Millisecond):
+		// the PutValue already returned, just give some margin to the last notifications
+	}
+	cidInfo.AddIpnsRecord(record)
+
+	tot, success, failed := record.GetSummary()
+	plog.Infof("IPNS record %s of cid %s published - %d total holders | %d successfull holders | %d failed holders",
+		record.Name.String(), cidInfo.CID.Hash().B58String(), tot, success, failed)
+}
+
+// provideDelegated provides the CID through the delegated routing endpoint of the given host,
+// returning the results of the provide as the round 0 of the delegated routing system
+func (publisher *CidPublisher) provideDelegated(
+	plog *log.Entry,
+	h *p2p.DHTHost,
+	cidInfo *models.CidInfo) *models.CidFetchResults {
+
+	fetchRes := models.NewCidFetchResults(cidInfo.CID, cidInfo.PublishTime, 0, publisher.K)
+	fetchRes.RoutingSystem = models.DelegatedRouting
+	fetchRes.CreatorState = publisher.creatorStatus.getState(h.ID())
+	fetchRes.TotalHops = -1
+	fetchRes.HopsTreeDepth = -1
+	fetchRes.MinHopsToClosest = -1
+
+	pCtx, cancel := context.WithTimeout(publisher.ctx, publisher.PubInterval-1*time.Second)
+	defer cancel()
+
+	_, err := h.ProvideCidDelegated(pCtx, cidInfo)
+	fetchRes.FinishTime = time.Now()
+	if err != nil {
+		plog.Errorf("unable to provide cid %s through delegated routing - %s", cidInfo.CID.Hash().B58String(), err.Error())
+		fetchRes.RoutingError = err.Error()
+	}
+	return fetchRes
+}
+
+// provideFromProvider provides the CID from one of its extra providers once its delay since the
+// publication of the CID has passed, persisting the provide with the PR Holders of the provider,
+// which the pinger pings from then on
+func (publisher *CidPublisher) provideFromProvider(
+	plog *log.Entry,
+	cidInfo *models.CidInfo,
+	provider *models.CidProvider,
+	ongoingProvides *sync.Map) {
+
+	defer publisher.providersWG.Done()
+	h, ok := publisher.hostPool.GetHost(provider.Provider)
+	if !ok {
+		plog.Errorf("no publisher host for provider %s of cid %s", provider.Provider, cidInfo.CID.Hash().B58String())
+		return
+	}
+	select {
+	case <-time.After(time.Until(cidInfo.PublishTime.Add(provider.Delay))):
+	case <-publisher.studyDoneC:
+		return
+	case <-publisher.ctx.Done():
+		return
+	}
+
+	provideEvent := models.NewProvideEvent(cidInfo.CID, 0, cidInfo.GetPingCounter(), time.Now(), publisher.K)
+	publisher.provide(plog, h, cidInfo, provideEvent, ongoingProvides)
+	// persist the provider (and the peer_info of its PR Holders) before the pinger starts pinging them
+	persistedProvider := *provider
+	persistedProvider.Event = provideEvent
+	publisher.DBCli.AddCidProvider(&persistedProvider)
+	cidInfo.AddProviderEvent(provider.Index, provideEvent)
+
+	tot, success, failed := provideEvent.Results.GetSummary()
+	plog.Infof("Cid %s provided by provider %d (host %d) - %d total PRHolders | %d successfull PRHolders | %d failed PRHolders",
+		cidInfo.CID.Hash().B58String(), provider.Index, provider.HostID, tot, success, failed)
+}
+
+// provide runs the provide operation of the given provide event, waiting until all the ADD_PROVIDER
+// messages of the event have been tracked by the msg listener (but not longer than the PubInterval)
+func (publisher *CidPublisher) provide(
+	plog *log.Entry,
+	h *p2p.DHTHost,
+	cidInfo *models.CidInfo,
+	provideEvent *models.ProvideEvent,
+	ongoingProvides *sync.Map) {
+
+	if publisher.dhtProvide == p2p.FullRTProvide {
+		publisher.provideBatch(plog, h, []*models.CidInfo{cidInfo}, []*models.ProvideEvent{provideEvent}, ongoingProvides)
+		return
+	}
+
+	provideKey := ongoingProvideKey(h.GetHostID(), cidInfo.CID.Hash())
+	fetchRes := provideEvent.Results
+	ongoingProvides.Store(provideKey, &ongoingProvide{cidInfo, provideEvent})
+	defer ongoingProvides.Delete(provideKey)
+
+	pCtx, cancel := context.WithTimeout(publisher.ctx, publisher.PubInterval-1*time.Second)
+	defer cancel()
+
+	fetchRes.CreatorState = publisher.creatorStatus.getState(h.ID())
+	traceCtx, stopTrace := h.TraceLookup(pCtx, cidInfo)
+	reqTime, lookupMetrics, err := h.ProvideCid(traceCtx, cidInfo)
+	fetchRes.AddLookup(models.NewLookup(cidInfo.CID, provideEvent.Round, models.ProvideLookup, stopTrace()))
+	if err != nil {
+		plog.Errorf("unable to Provide content. %s", err.Error())
+		provideEvent.Error = err.Error()
+		fetchRes.RoutingError = err.Error()
+	}
+	provideEvent.ProvideDuration = reqTime
+	if lookupMetrics != nil {
+		fetchRes.TotalHops = lookupMetrics.GetTotalHops()
+		fetchRes.HopsTreeDepth = lookupMetrics.GetTreeDepth()
+		fetchRes.MinHopsToClosest = lookupMetrics.GetMinHopsForPeerSet(lookupMetrics.GetClosestPeers())
+		// closest peers at publication time (round 0)
+		for _, closestPeer := range lookupMetrics.GetClosestPeers() {
+			fetchRes.AddClosestPeer(closestPeer)
+		}
+	} else {
+		fetchRes.TotalHops = -1
+		fetchRes.HopsTreeDepth = -1
+		fetchRes.MinHopsToClosest = -1
+	}
+
+	// Make sure we have received all the messages from the publication
+	// but do not wait forever
+	select {
+	case <-fetchRes.DoneC:
+		plog.Trace("finished publishing cid")
+	case <-pCtx.Done():
+		plog.Warnf("timeout publishing CID reached")
+		// give 500 ms extra to see if we receive any not from the msg-notifier
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// provideBatch provides all the given CIDs at once through the accelerated DHT client. The ADD_PROVIDER
+// messages of the batch still go through the msg notifier, so each provide event gets the results of its
+// own PR Holders, as with the rest of operations. There is no lookup, so the hop metrics are left undefined
+func (publisher *CidPublisher) provideBatch(
+	plog *log.Entry,
+	h *p2p.DHTHost,
+	cidInfos []*models.CidInfo,
+	provideEvents []*models.ProvideEvent,
+	ongoingProvides *sync.Map) {
+
+	for i, cidInfo := range cidInfos {
+		provideKey := ongoingProvideKey(h.GetHostID(), cidInfo.CID.Hash())
+		ongoingProvides.Store(provideKey, &ongoingProvide{cidInfo, provideEvents[i]})
+		defer ongoingProvides.Delete(provideKey)
+	}
+
+	pCtx, cancel := context.WithTimeout(publisher.ctx, publisher.PubInterval-1*time.Second)
+	defer cancel()
+
+	creatorState := publisher.creatorStatus.getState(h.ID())
+	reqTime, err := h.ProvideCidBatch(pCtx, cidInfos)
+	if err != nil {
+		plog.Errorf("unable to Provide batch of %d cids. %s", len(cidInfos), err.Error())
+	}
+	for _, provideEvent := range provideEvents {
+		fetchRes := provideEvent.Results
+		if err != nil {
+			provideEvent.Error = err.Error()
+			fetchRes.RoutingError = err.Error()
+		}
+		provideEvent.ProvideDuration = reqTime
+		fetchRes.CreatorState = creatorState
+		fetchRes.TotalHops = -1
+		fetchRes.HopsTreeDepth = -1
+		fetchRes.MinHopsToClosest = -1
+	}
+
+	// Make sure we have received all the messages from the publication of each CID
+	// but do not wait forever
+	for _, provideEvent := range provideEvents {
+		select {
+		case <-provideEvent.Results.DoneC:
+		case <-pCtx.Done():
+			plog.Warnf("timeout publishing CID batch reached")
+			// give 500 ms extra to see if we receive any not from the msg-notifier
+			time.Sleep(500 * time.Millisecond)
+			return
+		}
+	}
+	plog.Tracef("finished publishing batch of %d cids", len(cidInfos))
+}
+
+// republishingProcess provides again the CIDs that are still being tracked every RepublishInterval
+// (as the IPFS nodes reprovide their content), persisting each republish as a new provide event with
+// its own set of PR Holders. The new PR Holders are added to the ones that the pinger keeps track of
+func (publisher *CidPublisher) republishingProcess(
+	republisherWG *sync.WaitGroup,
+	initialPubDoneC chan struct{},
+	ongoingProvides *sync.Map) {
+
+	defer republisherWG.Done()
+	plog := log.WithField("mod", "republisher")
+	plog.Infof("republishing the cids every %s", publisher.RepublishInterval)
+
+	// limit the concurrent republishes to the number of publishers
+	var republishWG sync.WaitGroup
+	republishSlots := make(chan struct{}, publisher.Workers)
+	defer republishWG.Wait()
+
+	initialPubDone := false
+	minIterTicker := time.NewTicker(minIterTime)
+	for {
+		select {
+		case <-publisher.ctx.Done():
+			plog.Info("shutdown detected, closing republisher")
+			return
+		case <-publisher.studyDoneC:
+			plog.Info("study finished, closing republisher")
+			return
+		case <-initialPubDoneC:
+			initialPubDone = true
+			initialPubDoneC = nil
+		case <-minIterTicker.C:
+		}
+
+		trackedCids := 0
+		for _, cidInfo := range publisher.cidSet.getCidList() {
+			if cidInfo.IsFinished() {
+				continue
+			}
+			trackedCids++
+			if !cidInfo.IsReadyForRepublish(publisher.RepublishInterval) {
+				continue
+			}
+			// track the republish straight away, so that it isn't triggered again
+			provideEvent := models.NewProvideEvent(
+				cidInfo.CID,
+				cidInfo.GetProvideRounds(),
+				cidInfo.GetPingCounter(),
+				time.Now(),
+				publisher.K)
+			cidInfo.AddProvideEvent(provideEvent)
+
+			republishSlots <- struct{}{}
+			republishWG.Add(1)
+			go func(cidInfo *models.CidInfo, provideEvent *models.ProvideEvent) {
+				defer func() {
+					<-republishSlots
+					republishWG.Done()
+				}()
+				publisher.provide(plog, publisher.creatorHost(cidInfo), cidInfo, provideEvent, ongoingProvides)
+				publisher.DBCli.AddProvideEvent(cidInfo, provideEvent)
+
+				tot, success, failed := provideEvent.Results.GetSummary()
+				plog.Infof("Cid %s republished (round %d) - %d total PRHolders | %d successfull PRHolders | %d failed PRHolders | %d new PRHolders",
+					cidInfo.CID.Hash().B58String(), provideEvent.Round, tot, success, failed, provideEvent.NewPRHolders)
+			}(cidInfo, provideEvent)
+		}
+		// once every CID has been published and they are no longer tracked, we are done
+		if initialPubDone && trackedCids == 0 {
+			plog.Info("no more cids to republish, closing republisher")
+			return
+		}
+	}
+}
+
 // printSummary shows in the stdout the publication summary of a given CID
 func (publisher *CidPublisher) printSummary(logE *log.Entry, cInfo *models.CidInfo, round int) {
 	// Calculate success ratio on adding PR into PRHolders
@@ -0,0 +1,65 @@
+package hoarder
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
+	"github.com/cortze/ipfs-cid-hoarder/pkg/p2p"
+
+	pb "github.com/libp2p/go-libp2p-kad-dht/pb"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	log "github.com/sirupsen/logrus"
+)
+
+// testPublisherHost stands in for the publisher hosts, which only need to be identified to track the PR Holders
+type testPublisherHost struct {
+	hostID int
+	id     peer.ID
+}
+
+func (h *testPublisherHost) GetHostID() int                         { return h.hostID }
+func (h *testPublisherHost) ID() peer.ID                            { return h.id }
+func (h *testPublisherHost) GetMAddrsOfPeer(peer.ID) []ma.Multiaddr { return nil }
+func (h *testPublisherHost) GetUserAgentOfPeer(peer.ID) string      { return "test" }
+
+func TestTrackAddProviderNonSha256(t *testing.T) {
+	for _, spec := range []string{"v1/raw/blake3", "v1/dag-pb/sha3-256", "v0/dag-pb/sha2-256"} {
+		prefix, err := models.ParseCidPrefix(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := prefix.Prefix.Sum([]byte(spec))
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := &testPublisherHost{hostID: 1, id: "creator"}
+		cidInfo := models.NewCidInfo(c, 20, time.Hour, 48*time.Hour, "test", "")
+		cidInfo.AddCreator(h.ID())
+		provideEvent := models.NewProvideEvent(c, 0, 0, time.Now(), 20)
+
+		var ongoingProvides sync.Map
+		ongoingProvides.Store(ongoingProvideKey(h.GetHostID(), c.Hash()), &ongoingProvide{cidInfo, provideEvent})
+
+		// the ADD_PROVIDER messages are keyed by the multihash of the CID
+		for _, holder := range []peer.ID{"holder-1", "holder-2"} {
+			trackAddProvider(log.WithField("test", spec), h, &ongoingProvides, &p2p.MsgNotification{
+				RemotePeer: holder,
+				QueryTime:  time.Now(),
+				Msg:        *pb.NewMessage(pb.Message_ADD_PROVIDER, c.Hash(), 0),
+			})
+		}
+		if n := len(provideEvent.GetPRHolders()); n != 2 {
+			t.Fatalf("%s: expected 2 PR Holders, got %d", spec, n)
+		}
+		// the CidInfo only gets the PR Holders of the accepted attempt, once the publication finishes
+		if n := len(cidInfo.GetPRHolders()); n != 0 {
+			t.Fatalf("%s: expected no PR Holders before the publication finishes, got %d", spec, n)
+		}
+		if tot, success, _ := provideEvent.Results.GetSummary(); tot != 2 || success != 2 {
+			t.Fatalf("%s: expected 2 successful ADD_PROVIDER results, got %d/%d", spec, success, tot)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package hoarder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/ipfs/boxo/blockservice"
+	"github.com/ipfs/boxo/blockstore"
+	chunker "github.com/ipfs/boxo/chunker"
+	offline "github.com/ipfs/boxo/exchange/offline"
+	"github.com/ipfs/boxo/ipld/merkledag"
+	"github.com/ipfs/boxo/ipld/unixfs/importer/balanced"
+	"github.com/ipfs/boxo/ipld/unixfs/importer/helpers"
+	"github.com/ipfs/boxo/ipld/unixfs/importer/trickle"
+	uio "github.com/ipfs/boxo/ipld/unixfs/io"
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Layouts of the UnixFS DAGs
+const (
+	BalancedDagLayout = "balanced"
+	TrickleDagLayout  = "trickle"
+)
+
+// Provide strategies for the imported UnixFS DAGs
+const (
+	RootProvideStrategy      = "root"       // only the root of the import
+	FileRootsProvideStrategy = "file-roots" // the root of the DAG of each imported file and directory
+	AllProvideStrategy       = "all"        // every block of the DAG (Kubo's "all")
+)
+
+// unixfsCidSource imports local files or directories as UnixFS DAGs, serving the CIDs
+// that have to be provided following the provide strategy. The blocks of the DAGs are kept
+// in the given blockstore (an in-memory one if none is given)
+type unixfsCidSource struct {
+	cids    []*GeneratedCid
+	pointer int
+}
+
+func newUnixFSCidSource(
+	bs blockstore.Blockstore,
+	importPath, chunkerSpec, layout, strategy string,
+	prefix cid.Prefix,
+	limit int) (*unixfsCidSource, error) {
+
+	if bs == nil {
+		bs = blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+	}
+	imp := &dagImporter{
+		ctx:     context.Background(),
+		dagServ: merkledag.NewDAGService(blockservice.New(bs, offline.Exchange(bs))),
+		chunker: chunkerSpec,
+		layout:  layout,
+		// same as Kubo: raw leaves unless the DAG is made of CIDv0
+		rawLeaves: prefix.Version > 0,
+		cidBuilder: cid.Prefix{
+			Version:  prefix.Version,
+			Codec:    cid.DagProtobuf,
+			MhType:   prefix.MhType,
+			MhLength: prefix.MhLength,
+		},
+		roots: make([]cid.Cid, 0),
+	}
+	root, err := imp.importPath(importPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "importing "+importPath)
+	}
+
+	var toProvide []cid.Cid
+	switch strategy {
+	case RootProvideStrategy:
+		toProvide = []cid.Cid{root.Cid()}
+	case FileRootsProvideStrategy:
+		// the roots are added after their children, start from the root of the import
+		toProvide = make([]cid.Cid, 0, len(imp.roots))
+		for i := len(imp.roots) - 1; i >= 0; i-- {
+			toProvide = append(toProvide, imp.roots[i])
+		}
+	case AllProvideStrategy:
+		toProvide, err = imp.dagBlocks(root)
+		if err != nil {
+			return nil, errors.Wrap(err, "walking the dag of "+importPath)
+		}
+	default:
+		return nil, errors.New("unknown provide strategy " + strategy)
+	}
+
+	cids := make([]*GeneratedCid, 0, len(toProvide))
+	seen := make(map[cid.Cid]struct{})
+	for _, c := range toProvide {
+		// the same content can appear several times in the DAG
+		if _, ok := seen[c]; ok {
+			continue
+		}
+		seen[c] = struct{}{}
+		// size of the content under the node (the entire file or directory for the roots)
+		node, err := imp.dagServ.Get(imp.ctx, c)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading block "+c.String())
+		}
+		size, err := node.Size()
+		if err != nil {
+			return nil, errors.Wrap(err, "reading the size of block "+c.String())
+		}
+		cids = append(cids, &GeneratedCid{
+			CID:         c,
+			Source:      UnixFSCidSource,
+			DagRoot:     root.Cid(),
+			ContentSize: int(size),
+			Index:       len(cids),
+		})
+	}
+	log.WithField("mod", "cid-source").Infof("imported %s as dag %s, %d cids to provide with the %s strategy",
+		importPath, root.Cid(), len(cids), strategy)
+	// allow cid-number = -1 to provide the entire DAG
+	if limit > 0 && len(cids) > limit {
+		cids = cids[:limit]
+	}
+	return &unixfsCidSource{
+		cids: cids,
+	}, nil
+}
+
+func (s *unixfsCidSource) Type() string {
+	return UnixFSCidSource
+}
+
+func (s *unixfsCidSource) GetNewCid() (*GeneratedCid, error) {
+	if s.pointer >= len(s.cids) {
+		return nil, CidLimitError
+	}
+	genCid := s.cids[s.pointer]
+	s.pointer++
+	return genCid, nil
+}
+
+// dagImporter chunks files and directories into UnixFS DAGs, keeping track of the root of each of them
+type dagImporter struct {
+	ctx        context.Context
+	dagServ    ipld.DAGService
+	chunker    string
+	layout     string
+	rawLeaves  bool
+	cidBuilder cid.Builder
+	roots      []cid.Cid
+}
+
+func (imp *dagImporter) importPath(path string) (ipld.Node, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return imp.importDir(path)
+	}
+	return imp.importFile(path)
+}
+
+func (imp *dagImporter) importFile(path string) (ipld.Node, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	spl, err := chunker.FromString(f, imp.chunker)
+	if err != nil {
+		return nil, errors.Wrap(err, "composing chunker")
+	}
+	params := helpers.DagBuilderParams{
+		Maxlinks:   helpers.DefaultLinksPerBlock,
+		RawLeaves:  imp.rawLeaves,
+		CidBuilder: imp.cidBuilder,
+		Dagserv:    imp.dagServ,
+	}
+	dagBuilder, err := params.New(spl)
+	if err != nil {
+		return nil, err
+	}
+	var node ipld.Node
+	switch imp.layout {
+	case TrickleDagLayout:
+		node, err = trickle.Layout(dagBuilder)
+	default:
+		node, err = balanced.Layout(dagBuilder)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "chunking "+path)
+	}
+	imp.roots = append(imp.roots, node.Cid())
+	return node, nil
+}
+
+func (imp *dagImporter) importDir(path string) (ipld.Node, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	dir := uio.NewDirectory(imp.dagServ)
+	dir.SetCidBuilder(imp.cidBuilder)
+	for _, entry := range entries {
+		// skip symlinks and any other special file
+		if !entry.IsDir() && !entry.Type().IsRegular() {
+			continue
+		}
+		child, err := imp.importPath(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if err := dir.AddChild(imp.ctx, entry.Name(), child); err != nil {
+			return nil, errors.Wrap(err, "adding "+entry.Name()+" to the directory")
+		}
+	}
+	node, err := dir.GetNode()
+	if err != nil {
+		return nil, err
+	}
+	if err := imp.dagServ.Add(imp.ctx, node); err != nil {
+		return nil, err
+	}
+	imp.roots = append(imp.roots, node.Cid())
+	return node, nil
+}
+
+// dagBlocks returns the CIDs of all the blocks of the DAG, starting from the root (breadth-first)
+func (imp *dagImporter) dagBlocks(root ipld.Node) ([]cid.Cid, error) {
+	blocks := []cid.Cid{root.Cid()}
+	for i := 0; i < len(blocks); i++ {
+		node, err := imp.dagServ.Get(imp.ctx, blocks[i])
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range node.Links() {
+			blocks = append(blocks, link.Cid)
+		}
+	}
+	return blocks, nil
+}
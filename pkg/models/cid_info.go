@@ -8,6 +8,13 @@ import (
 	"github.com/libp2p/go-libp2p/core/peer"
 )
 
+// Status of the publication of a CID once all its provide attempts are done
+const (
+	ProvidedCid  = "provided"
+	DegradedCid  = "degraded"  // tracked, although its PRs didn't reach enough PR Holders
+	DiscardedCid = "discarded" // not tracked, as its PRs didn't reach enough PR Holders
+)
+
 // CidInfo contains the basic information of the CID to track.
 // It also includes the information about the PRHolders and the fetch results of all the fetch attempts.
 type CidInfo struct {
@@ -20,16 +27,35 @@ type CidInfo struct {
 	ProvideTime time.Duration // time that took to publish the provider records
 
 	K             int         // Number of K peers that should get the initial PR
-	PRHolders     []*PeerInfo // Peers that took the responsability to keep the PR
+	PRHolders     []*PeerInfo // Peers that took the responsability to keep the PR (over all the provide rounds)
 	PRPingResults []*CidFetchResults
+	ProvideEvents []*ProvideEvent // Initial publication and republishes of the PRs
+
+	ProvideStatus   string // provided, degraded or discarded
+	ProvideAttempts int    // attempts needed for the initial publication
+
+	ProvideOp    string    // Provide operation used to publish the PRs of the CID
+	Source       string    // Track where is the content coming from (random-content-gen, text-file, json-file, car-file)
+	TargetRegion string    // Region of the hash space that the CID was generated for (empty if it wasn't targeted)
+	Creator      peer.ID   // Peer hosting the content (us when publishing, first provider found when discovering)
+	Creators     []peer.ID // All the peers tracked as providers of the content (Creator included)
+	CreatorHost  int       // ID of the publisher host that provides the content (-1 when it isn't ours)
 
-	ProvideOp string  // Track where is the content coming from (Random, Cid-File, Bitswap)
-	Creator   peer.ID // Peer hosting the content, so far only one (us)
+	Providers  []*CidProvider // Publisher hosts providing the content (only the Creator unless multi-provider)
+	IpnsRecord *IpnsRecord    // IPNS record pointing to the CID (nil if it wasn't published)
+
+	DagRoot         cid.Cid // Root of the DAG that the CID belongs to (undefined if it isn't part of a DAG)
+	ContentSize     int     // Size in bytes of the content behind the CID (-1 if unknown)
+	GenerationID    int     // Generation (seed and params of the run) that produced the CID
+	GenerationIndex int     // Position of the CID in the sequence of the generation
 
 	ReqInterval   time.Duration
 	StudyDuration time.Duration
 	NextPing      time.Time
 	pingCounter   int
+
+	pingSchedule *PingSchedule // offsets since the publication of each ping round
+	lastRound    bool          // the schedule has no more rounds after the current one
 }
 
 // NewCidInfo creates the basic CID info struct that covers all the metadata and details of the
@@ -42,17 +68,48 @@ func NewCidInfo(
 	provOp string,
 	creator peer.ID) *CidInfo {
 
-	return &CidInfo{
-		CID:           id,
-		GenTime:       time.Now(), // fill the CID with the current time
-		K:             0,
-		PRPingResults: make([]*CidFetchResults, 0, k),
-		PRHolders:     make([]*PeerInfo, 0),
-		ProvideOp:     provOp,
-		Creator:       creator,
-		ReqInterval:   reqInt,
-		StudyDuration: studyDurt,
+	cidInfo := &CidInfo{
+		CID:             id,
+		GenTime:         time.Now(), // fill the CID with the current time
+		K:               0,
+		PRPingResults:   make([]*CidFetchResults, 0, k),
+		PRHolders:       make([]*PeerInfo, 0),
+		ProvideEvents:   make([]*ProvideEvent, 0),
+		ProvideStatus:   ProvidedCid,
+		ProvideAttempts: 1,
+		ProvideOp:       provOp,
+		Creators:        make([]peer.ID, 0),
+		CreatorHost:     -1,
+		Providers:       make([]*CidProvider, 0),
+		ContentSize:     -1,
+		ReqInterval:     reqInt,
+		StudyDuration:   studyDurt,
+	}
+	cidInfo.pingSchedule = &PingSchedule{Mode: EveryPingSchedule, Steps: []PingStep{{Interval: reqInt}}}
+	// the creator might not be known yet (i.e. discovered CIDs)
+	if creator != "" {
+		cidInfo.AddCreator(creator)
 	}
+	return cidInfo
+}
+
+// AddPingSchedule sets the schedule of the ping rounds of the CID (a round every ReqInterval by default)
+func (c *CidInfo) AddPingSchedule(schedule *PingSchedule) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.pingSchedule = schedule
+}
+
+// GetPlannedOffset returns the offset since the publication at which the given ping round was planned
+// (the first round is planned once half of the provide time has passed, as the PRs are sent along the provide)
+func (c *CidInfo) GetPlannedOffset(round int) time.Duration {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	if round <= 0 {
+		return 0
+	}
+	offset, _ := c.pingSchedule.Offset(round)
+	return c.ProvideTime/2 + offset
 }
 
 // IsInit return a boolean depending on whether the
@@ -67,6 +124,14 @@ func (c *CidInfo) AddPublicationTime(pubTime time.Time) {
 	c.PublishTime = pubTime
 }
 
+// AddProvideStatus sets the outcome of the initial publication and the attempts it needed
+func (c *CidInfo) AddProvideStatus(status string, attempts int) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.ProvideStatus = status
+	c.ProvideAttempts = attempts
+}
+
 // AddProvideTime modifies the time that took to publish a CID
 func (c *CidInfo) AddProvideTime(reqTime time.Duration) {
 	c.m.Lock()
@@ -74,11 +139,125 @@ func (c *CidInfo) AddProvideTime(reqTime time.Duration) {
 	c.ProvideTime = reqTime
 }
 
-// AddCreator aggregates the Peer.ID of the host/client that created the CID
+// AddSource tracks the origin of the CID (generated or read from a file)
+func (c *CidInfo) AddSource(source string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.Source = source
+}
+
+// AddGeneration links the CID to the generation that produced it, and its position in the generation
+func (c *CidInfo) AddGeneration(genID, genIndex int) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.GenerationID = genID
+	c.GenerationIndex = genIndex
+}
+
+// AddDagRoot links the CID to the root of the DAG that it belongs to
+func (c *CidInfo) AddDagRoot(root cid.Cid) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.DagRoot = root
+}
+
+// AddContentSize sets the size in bytes of the content behind the CID
+func (c *CidInfo) AddContentSize(size int) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.ContentSize = size
+}
+
+// AddTargetRegion sets the region of the hash space that the CID was generated for
+func (c *CidInfo) AddTargetRegion(region string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.TargetRegion = region
+}
+
+// AddCreator aggregates the Peer.ID of a host/client that provides the CID,
+// the first one added is considered the main Creator of the CID
 func (c *CidInfo) AddCreator(creator peer.ID) {
 	c.m.Lock()
 	defer c.m.Unlock()
-	c.Creator = creator
+	for _, p := range c.Creators {
+		if p == creator {
+			return
+		}
+	}
+	if c.Creator == "" {
+		c.Creator = creator
+	}
+	c.Creators = append(c.Creators, creator)
+}
+
+// GetCreators returns a copy of all the tracked providers of the CID
+func (c *CidInfo) GetCreators() []peer.ID {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	creators := make([]peer.ID, len(c.Creators))
+	copy(creators, c.Creators)
+	return creators
+}
+
+// AddCreatorHost sets the ID of the publisher host that provides the CID
+func (c *CidInfo) AddCreatorHost(hostID int) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.CreatorHost = hostID
+}
+
+// AddProvider tracks one of the publisher hosts that provide the CID
+func (c *CidInfo) AddProvider(provider *CidProvider) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.Providers = append(c.Providers, provider)
+}
+
+// AddProviderEvent sets the provide of the provider at the given index, once it's done
+func (c *CidInfo) AddProviderEvent(idx int, event *ProvideEvent) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.Providers[idx].Event = event
+}
+
+// GetExtraProviders returns a copy of the providers of the CID, besides its creator, that already provided it
+func (c *CidInfo) GetExtraProviders() []CidProvider {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	providers := make([]CidProvider, 0, len(c.Providers))
+	for _, provider := range c.Providers {
+		if provider.Index > 0 && provider.Event != nil {
+			providers = append(providers, *provider)
+		}
+	}
+	return providers
+}
+
+// AddIpnsRecord sets the IPNS record published for the CID
+func (c *CidInfo) AddIpnsRecord(record *IpnsRecord) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.IpnsRecord = record
+}
+
+// GetIpnsRecord returns the IPNS record published for the CID (nil if none)
+func (c *CidInfo) GetIpnsRecord() *IpnsRecord {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return c.IpnsRecord
+}
+
+// IsCreator returns whether the given peer is one of the tracked providers of the CID
+func (c *CidInfo) IsCreator(p peer.ID) bool {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	for _, creator := range c.Creators {
+		if creator == p {
+			return true
+		}
+	}
+	return false
 }
 
 // AddPRHolder inserts a given Peer selected/attempted to keep the PRs for a CID
@@ -89,6 +268,68 @@ func (c *CidInfo) AddPRHolder(prHolder *PeerInfo) {
 	c.K++
 }
 
+// AddNewPRHolder inserts a Peer that got the PRs of the CID in a republish, returning false if the peer
+// was already one of the PR Holders of the CID (the initial K is not modified)
+func (c *CidInfo) AddNewPRHolder(prHolder *PeerInfo) bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+	for _, p := range c.PRHolders {
+		if p.ID == prHolder.ID {
+			return false
+		}
+	}
+	c.PRHolders = append(c.PRHolders, prHolder)
+	return true
+}
+
+// GetPRHolders returns a copy of the list of PR Holders of the CID
+func (c *CidInfo) GetPRHolders() []*PeerInfo {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	prHolders := make([]*PeerInfo, len(c.PRHolders))
+	copy(prHolders, c.PRHolders)
+	return prHolders
+}
+
+// GetOriginalPRHolders returns the PR Holders of the accepted attempt of the initial publication
+// (provide round 0), or all of them if the CID wasn't published by us
+func (c *CidInfo) GetOriginalPRHolders() []*PeerInfo {
+	c.m.RLock()
+	var publication *ProvideEvent
+	if len(c.ProvideEvents) > 0 {
+		publication = c.ProvideEvents[0]
+	}
+	c.m.RUnlock()
+	if publication == nil {
+		return c.GetPRHolders()
+	}
+	return publication.GetPRHolders()
+}
+
+// AddProvideEvent tracks a new provide round of the CID, as soon as it starts
+func (c *CidInfo) AddProvideEvent(event *ProvideEvent) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.ProvideEvents = append(c.ProvideEvents, event)
+}
+
+// GetProvideRounds returns the number of provide rounds of the CID (initial publication included)
+func (c *CidInfo) GetProvideRounds() int {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return len(c.ProvideEvents)
+}
+
+// IsReadyForRepublish returns true if the given interval has passed since the last provide round of the CID
+func (c *CidInfo) IsReadyForRepublish(interval time.Duration) bool {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	if len(c.ProvideEvents) == 0 {
+		return false
+	}
+	return time.Now().After(c.ProvideEvents[len(c.ProvideEvents)-1].ProvideTime.Add(interval))
+}
+
 // AddPRFetchResults inserts the results of a given CidFetch round into the CID struct
 func (c *CidInfo) AddPRFetchResults(results *CidFetchResults) {
 	c.m.Lock()
@@ -97,10 +338,10 @@ func (c *CidInfo) AddPRFetchResults(results *CidFetchResults) {
 	c.PRPingResults = append(c.PRPingResults, results)
 	// check if the CID is initialized or not
 	if c.NextPing.IsZero() {
-		// Update the next ping time to PublicationTime + req interval
+		// Update the next ping time to PublicationTime + the offset of the first round
 		// take also into account the publication time
-		offset := c.ProvideTime / 2
-		c.NextPing = c.PublishTime.Add(offset).Add(c.ReqInterval)
+		offset, _ := c.pingSchedule.Offset(1)
+		c.NextPing = c.PublishTime.Add(c.ProvideTime / 2).Add(offset)
 	}
 }
 
@@ -112,21 +353,26 @@ func (c *CidInfo) IsReadyForNextPing() bool {
 	return !c.NextPing.IsZero() && time.Now().After(c.NextPing)
 }
 
-// IsFinished returns true if the study for the given CID has already finished (enough ping rounds to cover the study time)
-// (taking into account the publicationTime previously calculated)
+// IsFinished returns true if the study for the given CID has already finished (enough ping rounds to cover the study time,
+// or no more rounds left in the ping schedule) (taking into account the publicationTime previously calculated)
 func (c *CidInfo) IsFinished() bool {
 	c.m.RLock()
 	defer c.m.RUnlock()
-	return time.Now().After(c.PublishTime.Add(c.StudyDuration))
+	return c.lastRound || time.Now().After(c.PublishTime.Add(c.StudyDuration))
 }
 
 // IncreasePingCounter increases the internal ping counter, later used to track the ping round
-// it also increases the time for the next ping
+// it also moves the next ping to the following round of the ping schedule
 func (c *CidInfo) IncreasePingCounter() {
 	c.m.Lock()
 	defer c.m.Unlock()
 	c.pingCounter++
-	c.NextPing = c.NextPing.Add(c.ReqInterval)
+	offset, ok := c.pingSchedule.Offset(c.pingCounter + 1)
+	if !ok {
+		c.lastRound = true
+		return
+	}
+	c.NextPing = c.PublishTime.Add(c.ProvideTime / 2).Add(offset)
 }
 
 // GetPingCounter returns the state of the internal pingCounter
@@ -20,6 +20,8 @@ type PRPingResults struct {
 	HasRecords        bool
 	RecordsWithMAddrs bool
 	ConError          string
+	OriginalHolder    bool // the peer got the PR in the initial publication (false for the PR Holders of the republishes and the closest peers)
+	ProviderIdx       int  // provider whose PR Holders include the peer (0 for the creator and the closest peers)
 }
 
 // NewPRPingResults creates a new struct with the basic status/performance info for each individual pings to PR Holders
@@ -46,6 +48,8 @@ func NewPRPingResults(
 		hasRecords,
 		recordsWithMAddrs,
 		connError,
+		true,
+		0,
 	}
 }
 
@@ -54,6 +58,15 @@ func (ping *PRPingResults) GetPingTimeSincePublication() time.Duration {
 	return ping.PingTime.Add(ping.PingDuration).Sub(ping.cidPubTime)
 }
 
+// FetchNotAttempted is the fetch error of the rounds where the content wasn't fetched (i.e. the publication)
+const FetchNotAttempted = "not_attempted"
+
+// Routing systems through which the CIDs are provided and looked up on each round
+const (
+	DHTRouting       = "dht"
+	DelegatedRouting = "delegated" // delegated routing HTTP endpoint
+)
+
 // CidFetchResults is the basic struct containing the summary of all the requests done for a given CID on a fetch round.
 type CidFetchResults struct {
 	m                     sync.RWMutex
@@ -71,9 +84,29 @@ type CidFetchResults struct {
 	PRPingResults         []*PRPingResults
 	IsRetrievable         bool
 	PRWithMAddr           bool
+	IsFetchable           bool          // the content was retrieved over Bitswap from the provider
+	FetchDialDuration     time.Duration // time to connect the provider before the fetch
+	FetchTTFB             time.Duration // time to the first block of the content, once connected to the provider
+	FetchedFrom           peer.ID       // peer that sent the block (empty if none did)
+	FetchError            string
+	CreatorState          string // whether the creator was online at the start of the round
 	ClosestPeers          []peer.ID
+	Lookup                *Lookup // graph of the DHT lookup of the round (nil if it wasn't traced)
 	Target                int
 	DoneC                 chan struct{}
+
+	ProvidersFound  int               // providers returned by the provider lookup
+	FirstProvider   peer.ID           // first provider returned by the lookup (empty if none)
+	ProviderResults []*ProviderResult // retrievability of each of the tracked providers
+	RoundProviders  []*RoundProvider  // every provider returned by the lookup, with its discovery
+	IpnsPingResults []*IpnsPingResult // GET_VALUE sent to each of the holders of the IPNS record (if any)
+
+	RoutingSystem string // routing system through which the round was measured
+	RoutingError  string // error of the provide or the provider lookup of the round (empty if none)
+
+	PlannedOffset time.Duration // offset since the publication at which the round was planned by the ping schedule
+
+	ClosestPeersInfo []*PeerInfo // info of the current closest peers that were pinged without being PR Holders
 }
 
 // NewCidFetchResults return the FetchResults struct that contains the basic information for the entire fetch round of a particular CID.
@@ -85,12 +118,59 @@ func NewCidFetchResults(contentID cid.Cid, pubTime time.Time, round int, target
 		StartTime:     time.Now(),
 		FinishTime:    time.Now(),
 		PRPingResults: make([]*PRPingResults, 0),
+		FetchError:    FetchNotAttempted,
+		CreatorState:  CreatorUnknown,
+		RoutingSystem: DHTRouting,
 		ClosestPeers:  make([]peer.ID, 0),
 		Target:        target, // K
 		DoneC:         make(chan struct{}, 1),
 	}
 }
 
+// AddClosestPeerPing inserts the result of pinging one of the current closest peers that wasn't a PR Holder
+func (c *CidFetchResults) AddClosestPeerPing(pInfo *PeerInfo, pingRes *PRPingResults) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	pingRes.OriginalHolder = false
+	c.ClosestPeersInfo = append(c.ClosestPeersInfo, pInfo)
+	c.PRPingResults = append(c.PRPingResults, pingRes)
+}
+
+// AddProviderHolderPing inserts the result of pinging one of the PR Holders of the extra provider
+// with the given index, which don't count in the summary of the PR Holders of the creator
+func (c *CidFetchResults) AddProviderHolderPing(providerIdx int, pingRes *PRPingResults) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	pingRes.ProviderIdx = providerIdx
+	c.PRPingResults = append(c.PRPingResults, pingRes)
+}
+
+// AddIpnsPingResult inserts the result of the GET_VALUE sent to one of the holders of the IPNS record
+func (c *CidFetchResults) AddIpnsPingResult(pingRes *IpnsPingResult) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.IpnsPingResults = append(c.IpnsPingResults, pingRes)
+}
+
+// AddProviders aggregates the providers returned by the provider lookup of the round,
+// composing the retrievability of each of the given creators
+func (c *CidFetchResults) AddProviders(creators []peer.ID, providers []peer.AddrInfo) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.ProvidersFound = len(providers)
+	if len(providers) > 0 {
+		c.FirstProvider = providers[0].ID
+	}
+	c.ProviderResults = NewProviderResults(creators, providers)
+}
+
+// AddRoundProviders adds the discovery of every provider returned by the provider lookup of the round
+func (c *CidFetchResults) AddRoundProviders(roundProviders []*RoundProvider) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.RoundProviders = roundProviders
+}
+
 // IsDone reports whether the entire Fetch Result has been completed
 func (c *CidFetchResults) IsDone() bool {
 	return len(c.PRPingResults) >= c.Target
@@ -125,8 +205,12 @@ func (c *CidFetchResults) GetFetchTimeSincePublication() time.Duration {
 func (c *CidFetchResults) GetSummary() (tot, success, failed int) {
 	c.m.RLock()
 	defer c.m.RUnlock()
-	// calculate the summary of the PingRound
+	// calculate the summary of the PingRound (only of the original PR Holders of the creator,
+	// not of the later ones, the rest of closest peers nor the PR Holders of the extra providers)
 	for _, pingRes := range c.PRPingResults {
+		if !pingRes.OriginalHolder || pingRes.ProviderIdx != 0 {
+			continue
+		}
 		tot++
 		if pingRes.Active {
 			success++
@@ -137,6 +221,13 @@ func (c *CidFetchResults) GetSummary() (tot, success, failed int) {
 	return tot, success, failed
 }
 
+// AddLookup adds the traced graph of the DHT lookup of the fetch round
+func (c *CidFetchResults) AddLookup(lookup *Lookup) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.Lookup = lookup
+}
+
 // AddClosestPeer inserts into the CidFetchResults a peer that is inside the K closest peers in the IPFS DHT in that fetch round.
 func (c *CidFetchResults) AddClosestPeer(pInfo peer.ID) {
 	c.m.Lock()
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	cid "github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multicodec"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/pkg/errors"
+)
+
+// DefaultCidPrefix is the CID format that the hoarder has always generated: CIDv1, raw, sha2-256
+const DefaultCidPrefix = "v1/raw/sha2-256"
+
+// CidPrefix is one of the CID formats (version, codec and multihash) that can be generated in a run,
+// together with the weight that it has in the mix of formats
+type CidPrefix struct {
+	Prefix cid.Prefix
+	Weight int
+}
+
+// ParseCidPrefix reads a CID prefix with the format <version>/<codec>/<mh-type>[/<mh-length>][:<weight>]
+// (i.e. "v1/raw/sha2-256", "v0/dag-pb/sha2-256:3", "v1/raw/blake3/32:1").
+// The multihash length defaults to the default length of the hash function, and the weight to 1
+func ParseCidPrefix(spec string) (CidPrefix, error) {
+	prefix := CidPrefix{
+		Prefix: cid.Prefix{MhLength: -1},
+		Weight: 1,
+	}
+
+	format, weight, hasWeight := strings.Cut(strings.TrimSpace(spec), ":")
+	if hasWeight {
+		w, err := strconv.Atoi(weight)
+		if err != nil || w <= 0 {
+			return prefix, errors.Errorf("cid prefix %q has an invalid weight, it has to be a positive integer", spec)
+		}
+		prefix.Weight = w
+	}
+
+	parts := strings.Split(format, "/")
+	if len(parts) < 3 || len(parts) > 4 {
+		return prefix, errors.Errorf("cid prefix %q doesn't follow <version>/<codec>/<mh-type>[/<mh-length>][:<weight>]", spec)
+	}
+	switch parts[0] {
+	case "v0":
+		prefix.Prefix.Version = 0
+	case "v1":
+		prefix.Prefix.Version = 1
+	default:
+		return prefix, errors.Errorf("cid prefix %q has an unknown cid version %q (v0, v1)", spec, parts[0])
+	}
+	var codec multicodec.Code
+	if err := codec.Set(parts[1]); err != nil {
+		return prefix, errors.Errorf("cid prefix %q has an unknown codec %q", spec, parts[1])
+	}
+	prefix.Prefix.Codec = uint64(codec)
+	mhType, ok := mh.Names[parts[2]]
+	if !ok {
+		return prefix, errors.Errorf("cid prefix %q has an unknown multihash function %q", spec, parts[2])
+	}
+	prefix.Prefix.MhType = mhType
+	if len(parts) == 4 {
+		mhLength, err := strconv.Atoi(parts[3])
+		if err != nil || mhLength <= 0 {
+			return prefix, errors.Errorf("cid prefix %q has an invalid multihash length %q", spec, parts[3])
+		}
+		prefix.Prefix.MhLength = mhLength
+	}
+
+	// CIDv0 are implicitly dag-pb and sha2-256 (32 bytes)
+	if prefix.Prefix.Version == 0 && (prefix.Prefix.Codec != cid.DagProtobuf || prefix.Prefix.MhType != mh.SHA2_256 ||
+		(prefix.Prefix.MhLength != -1 && prefix.Prefix.MhLength != 32)) {
+		return prefix, errors.Errorf("cid prefix %q is not valid, v0 only supports dag-pb/sha2-256", spec)
+	}
+	// make sure that the hash function is registered and supports the given length
+	if _, err := prefix.Prefix.Sum([]byte{}); err != nil {
+		return prefix, errors.Wrap(err, fmt.Sprintf("cid prefix %q", spec))
+	}
+	return prefix, nil
+}
+
+// ParseCidPrefixes parses the mix of CID prefixes of a run
+func ParseCidPrefixes(specs []string) ([]CidPrefix, error) {
+	if len(specs) == 0 {
+		specs = []string{DefaultCidPrefix}
+	}
+	prefixes := make([]CidPrefix, 0, len(specs))
+	for _, spec := range specs {
+		prefix, err := ParseCidPrefix(spec)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes, nil
+}
+
+// FormatCidPrefix returns the <version>/<codec>/<mh-type>/<mh-length> representation of the given prefix
+func FormatCidPrefix(prefix cid.Prefix) string {
+	mhName, ok := mh.Codes[prefix.MhType]
+	if !ok {
+		mhName = fmt.Sprintf("0x%x", prefix.MhType)
+	}
+	return fmt.Sprintf("v%d/%s/%s/%d", prefix.Version, multicodec.Code(prefix.Codec).String(), mhName, prefix.MhLength)
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"testing"
+
+	cid "github.com/ipfs/go-cid"
+	mh "github.com/multiformats/go-multihash"
+)
+
+func TestParseCidPrefix(t *testing.T) {
+	valid := []struct {
+		spec   string
+		prefix cid.Prefix
+		weight int
+		format string
+	}{
+		{"v1/raw/sha2-256", cid.Prefix{Version: 1, Codec: cid.Raw, MhType: mh.SHA2_256, MhLength: -1}, 1, "v1/raw/sha2-256/-1"},
+		{"v0/dag-pb/sha2-256:3", cid.Prefix{Version: 0, Codec: cid.DagProtobuf, MhType: mh.SHA2_256, MhLength: -1}, 3, "v0/dag-pb/sha2-256/-1"},
+		{"v1/dag-cbor/blake3/32:2", cid.Prefix{Version: 1, Codec: cid.DagCBOR, MhType: mh.BLAKE3, MhLength: 32}, 2, "v1/dag-cbor/blake3/32"},
+		{"v1/raw/sha3-256", cid.Prefix{Version: 1, Codec: cid.Raw, MhType: mh.SHA3_256, MhLength: -1}, 1, "v1/raw/sha3-256/-1"},
+	}
+	for _, test := range valid {
+		prefix, err := ParseCidPrefix(test.spec)
+		if err != nil {
+			t.Fatalf("%s: %s", test.spec, err)
+		}
+		if prefix.Prefix != test.prefix || prefix.Weight != test.weight {
+			t.Fatalf("%s: got %+v", test.spec, prefix)
+		}
+		if format := FormatCidPrefix(prefix.Prefix); format != test.format {
+			t.Fatalf("%s: formatted as %s", test.spec, format)
+		}
+	}
+
+	invalid := []string{
+		"v2/raw/sha2-256",
+		"v1/unknown-codec/sha2-256",
+		"v1/raw/sha2-257",
+		"v1/raw/sha2-256:0",
+		"v0/raw/sha2-256",
+		"v0/dag-pb/blake3",
+		"v1/raw",
+	}
+	for _, spec := range invalid {
+		if _, err := ParseCidPrefix(spec); err == nil {
+			t.Fatalf("%s: expected error", spec)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// CidProvider is each of the publisher hosts that provide the same CID in the multi-provider
+// experiments. The first one (index 0) is the Creator of the CID, which does the initial publication,
+// and the rest provide it once their delay since the publication has passed
+type CidProvider struct {
+	Index    int
+	Provider peer.ID
+	HostID   int
+	Delay    time.Duration
+	Event    *ProvideEvent // provide of the host, with its own PR Holders
+}
+
+// ProviderResult is the retrievability of each of the tracked providers of a CID on a ping round
+type ProviderResult struct {
+	Provider   peer.ID
+	Found      bool // returned by the lookup for the providers of the CID
+	Rank       int  // position among the returned providers (-1 if it wasn't returned)
+	WithMAddrs bool
+}
+
+// NewProviderResults composes the retrievability of each of the given creators out of
+// the providers returned by the provider lookup, in the order they were returned
+func NewProviderResults(creators []peer.ID, providers []peer.AddrInfo) []*ProviderResult {
+	results := make([]*ProviderResult, 0, len(creators))
+	for _, creator := range creators {
+		res := &ProviderResult{
+			Provider: creator,
+			Rank:     -1,
+		}
+		for rank, provider := range providers {
+			if provider.ID == creator {
+				res.Found = true
+				res.Rank = rank
+				res.WithMAddrs = len(provider.Addrs) > 0
+				break
+			}
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// RoundProvider is each of the providers returned by the provider lookup of a ping round,
+// whether it's one of the tracked providers of the CID or not
+type RoundProvider struct {
+	Provider        peer.ID
+	MultiAddrs      []ma.Multiaddr
+	Rank            int           // position among the returned providers
+	TimeToDiscovery time.Duration // since the start of the lookup until the response that returned it
+	Hop             int           // hop of the step that returned it (-1 if the lookup wasn't traced)
+	DiscoveredBy    peer.ID       // peer that returned it (empty if the lookup wasn't traced)
+	IsCreator       bool
+}
+
+// NewRoundProviders composes the discovery of each of the providers returned by the provider lookup
+// out of its traced steps, taking the first response that returned each provider. The providers that
+// don't show up in any step (i.e. untraced lookups or delegated routing) get the whole lookup duration
+func NewRoundProviders(
+	creators []peer.ID,
+	providers []peer.AddrInfo,
+	lookupStart time.Time,
+	lookupDuration time.Duration,
+	lookup *Lookup) []*RoundProvider {
+
+	isCreator := make(map[peer.ID]bool, len(creators))
+	for _, creator := range creators {
+		isCreator[creator] = true
+	}
+	results := make([]*RoundProvider, 0, len(providers))
+	for rank, provider := range providers {
+		res := &RoundProvider{
+			Provider:        provider.ID,
+			MultiAddrs:      provider.Addrs,
+			Rank:            rank,
+			TimeToDiscovery: lookupDuration,
+			Hop:             -1,
+			IsCreator:       isCreator[provider.ID],
+		}
+		if lookup != nil {
+			var first *LookupStep
+			for _, step := range lookup.Steps {
+				if !step.returnedProvider(provider.ID) {
+					continue
+				}
+				if first == nil || step.responseTime().Before(first.responseTime()) {
+					first = step
+				}
+			}
+			if first != nil {
+				res.TimeToDiscovery = first.responseTime().Sub(lookupStart)
+				res.Hop = first.Hop
+				res.DiscoveredBy = first.Peer
+			}
+		}
+		results = append(results, res)
+	}
+	return results
+}
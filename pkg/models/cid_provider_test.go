@@ -0,0 +1,93 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	cid "github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+func TestNewProviderResults(t *testing.T) {
+	maddr, err := ma.NewMultiaddr("/ip4/127.0.0.1/tcp/4001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	providers := []peer.AddrInfo{
+		{ID: "other"},
+		{ID: "b", Addrs: []ma.Multiaddr{maddr}},
+		{ID: "a"},
+	}
+	results := NewProviderResults([]peer.ID{"a", "b", "c"}, providers)
+
+	expected := []ProviderResult{
+		{Provider: "a", Found: true, Rank: 2},
+		{Provider: "b", Found: true, Rank: 1, WithMAddrs: true},
+		{Provider: "c", Rank: -1},
+	}
+	for i, res := range results {
+		if *res != expected[i] {
+			t.Fatalf("provider %s: expected %+v, got %+v", expected[i].Provider, expected[i], *res)
+		}
+	}
+}
+
+func TestNewRoundProviders(t *testing.T) {
+	start := time.Now()
+	step := func(p peer.ID, startMs, durMs int, returned []peer.ID, providers ...peer.ID) *LookupStep {
+		s := &LookupStep{
+			Peer:          p,
+			QueryTime:     start.Add(time.Duration(startMs) * time.Millisecond),
+			QueryDuration: time.Duration(durMs) * time.Millisecond,
+			ReturnedPeers: returned,
+		}
+		for _, prov := range providers {
+			s.ReturnedProviders = append(s.ReturnedProviders, peer.AddrInfo{ID: prov})
+		}
+		return s
+	}
+	// "a" is returned by x (hop 1) and later by y (hop 2), "b" only by y
+	lookup := NewLookup(cid.Undef, 1, ProvidersLookup, []*LookupStep{
+		step("x", 0, 100, []peer.ID{"y"}, "a"),
+		step("y", 120, 80, nil, "a", "b"),
+	})
+	providers := []peer.AddrInfo{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	results := NewRoundProviders([]peer.ID{"b"}, providers, start, time.Second, lookup)
+
+	expected := []RoundProvider{
+		{Provider: "a", Rank: 0, TimeToDiscovery: 100 * time.Millisecond, Hop: 1, DiscoveredBy: "x"},
+		{Provider: "b", Rank: 1, TimeToDiscovery: 200 * time.Millisecond, Hop: 2, DiscoveredBy: "y", IsCreator: true},
+		{Provider: "c", Rank: 2, TimeToDiscovery: time.Second, Hop: -1},
+	}
+	for i, res := range results {
+		if !reflect.DeepEqual(*res, expected[i]) {
+			t.Fatalf("provider %s: expected %+v, got %+v", expected[i].Provider, expected[i], *res)
+		}
+	}
+}
+
+func TestExtraProviderHolders(t *testing.T) {
+	c := cid.Undef
+	cidInfo := NewCidInfo(c, 20, time.Hour, 48*time.Hour, "test", "creator")
+	for idx, p := range []peer.ID{"creator", "second", "third"} {
+		cidInfo.AddProvider(&CidProvider{Index: idx, Provider: p})
+	}
+	cidInfo.AddProviderEvent(0, NewProvideEvent(c, 0, 0, time.Now(), 20))
+	cidInfo.AddProviderEvent(1, NewProvideEvent(c, 0, 0, time.Now(), 20))
+
+	// only the extra providers that already provided the CID are pinged
+	extra := cidInfo.GetExtraProviders()
+	if len(extra) != 1 || extra[0].Provider != "second" {
+		t.Fatalf("expected only the second provider, got %+v", extra)
+	}
+
+	// the pings of their PR Holders don't count in the summary of the creator
+	fetchRes := NewCidFetchResults(c, time.Now(), 1, 20)
+	fetchRes.AddPRPingResults(NewPRPingResults(c, "holder", 1, time.Now(), time.Now(), 0, true, true, true, ""))
+	fetchRes.AddProviderHolderPing(1, NewPRPingResults(c, "holder", 1, time.Now(), time.Now(), 0, false, false, false, "timeout"))
+	if tot, success, failed := fetchRes.GetSummary(); tot != 1 || success != 1 || failed != 0 {
+		t.Fatalf("expected 1/1/0 summary, got %d/%d/%d", tot, success, failed)
+	}
+}
@@ -10,3 +10,52 @@ type ClosestPeers struct {
 	PingRound int
 	Peers     []peer.ID
 }
+
+// ClosestPeersOverlap compares the closest peers to the CID on a ping round with the original PR Holders
+// (the ones of the initial publication), to spot the records stranded on peers that are no longer close
+type ClosestPeersOverlap struct {
+	Cid          cid.Cid
+	PingRound    int
+	ClosestPeers int
+	PRHolders    int
+	Overlap      int       // PR Holders that are still among the closest peers
+	Joined       []peer.ID // closest peers that aren't PR Holders
+	Left         []peer.ID // PR Holders that are no longer among the closest peers
+}
+
+// NewClosestPeersOverlap compares the closest peers of the fetch round with the given PR Holders,
+// returning nil if there are no closest peers to compare with (i.e. the lookup failed)
+func NewClosestPeersOverlap(fetchRes *CidFetchResults, prHolders []*PeerInfo) *ClosestPeersOverlap {
+	fetchRes.m.RLock()
+	defer fetchRes.m.RUnlock()
+	if len(fetchRes.ClosestPeers) == 0 {
+		return nil
+	}
+	overlap := &ClosestPeersOverlap{
+		Cid:          fetchRes.Cid,
+		PingRound:    fetchRes.Round,
+		ClosestPeers: len(fetchRes.ClosestPeers),
+		PRHolders:    len(prHolders),
+		Joined:       make([]peer.ID, 0),
+		Left:         make([]peer.ID, 0),
+	}
+	closest := make(map[peer.ID]struct{}, len(fetchRes.ClosestPeers))
+	for _, p := range fetchRes.ClosestPeers {
+		closest[p] = struct{}{}
+	}
+	holders := make(map[peer.ID]struct{}, len(prHolders))
+	for _, p := range prHolders {
+		holders[p.ID] = struct{}{}
+		if _, ok := closest[p.ID]; ok {
+			overlap.Overlap++
+		} else {
+			overlap.Left = append(overlap.Left, p.ID)
+		}
+	}
+	for _, p := range fetchRes.ClosestPeers {
+		if _, ok := holders[p]; !ok {
+			overlap.Joined = append(overlap.Joined, p)
+		}
+	}
+	return overlap
+}
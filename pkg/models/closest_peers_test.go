@@ -0,0 +1,28 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	cid "github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestClosestPeersOverlap(t *testing.T) {
+	fetchRes := NewCidFetchResults(cid.Undef, time.Now(), 3, 3)
+	if NewClosestPeersOverlap(fetchRes, nil) != nil {
+		t.Fatal("no overlap expected without closest peers")
+	}
+	for _, p := range []peer.ID{"a", "b", "d"} {
+		fetchRes.AddClosestPeer(p)
+	}
+	holders := []*PeerInfo{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+
+	overlap := NewClosestPeersOverlap(fetchRes, holders)
+	if overlap.PingRound != 3 || overlap.ClosestPeers != 3 || overlap.PRHolders != 3 || overlap.Overlap != 2 {
+		t.Fatalf("wrong overlap %+v", overlap)
+	}
+	if len(overlap.Joined) != 1 || overlap.Joined[0] != "d" || len(overlap.Left) != 1 || overlap.Left[0] != "c" {
+		t.Fatalf("wrong joined %v or left %v peers", overlap.Joined, overlap.Left)
+	}
+}
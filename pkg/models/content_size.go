@@ -0,0 +1,206 @@
+package models
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Supported distributions to sample the size of the generated content
+const (
+	FixedContentSize     = "fixed"
+	UniformContentSize   = "uniform"
+	LogNormalContentSize = "lognormal"
+	HistogramContentSize = "histogram"
+)
+
+// MaxContentSize is the biggest block that can be generated, as Bitswap doesn't transfer blocks over 2MiB
+const MaxContentSize = 2 << 20
+
+// ContentSizeDist is the distribution of the size (in bytes) of the generated content:
+//   - fixed:<bytes>                 every block has the same size
+//   - uniform:<min>-<max>           sizes are uniformly distributed between min and max (both included)
+//   - lognormal:<median>:<sigma>    the log of the size follows a normal distribution centered in the log of the median
+//   - histogram:<file>              sizes are sampled from an empirical histogram, one "<min> <max> <count>" bin per line
+type ContentSizeDist struct {
+	Mode   string
+	Min    int
+	Max    int
+	Median float64
+	Sigma  float64
+	Bins   []ContentSizeBin
+
+	totalCount float64
+}
+
+// ContentSizeBin is one of the bins of an empirical histogram of content sizes
+type ContentSizeBin struct {
+	Min   int
+	Max   int
+	Count float64
+}
+
+// ParseContentSizeDist reads the distribution of the content size from its spec,
+// which defaults to the given fixed size when the spec is empty
+func ParseContentSizeDist(spec string, fixedSize int) (*ContentSizeDist, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		spec = fmt.Sprintf("%s:%d", FixedContentSize, fixedSize)
+	}
+	mode, args, _ := strings.Cut(spec, ":")
+	dist := &ContentSizeDist{Mode: mode}
+	switch mode {
+	case FixedContentSize:
+		size, err := parseContentSize(args)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("content size %q", spec))
+		}
+		dist.Min, dist.Max = size, size
+
+	case UniformContentSize:
+		minStr, maxStr, ok := strings.Cut(args, "-")
+		if !ok {
+			return nil, errors.Errorf("content size %q doesn't follow uniform:<min>-<max>", spec)
+		}
+		min, err := parseContentSize(minStr)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("content size %q", spec))
+		}
+		max, err := parseContentSize(maxStr)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("content size %q", spec))
+		}
+		if min > max {
+			return nil, errors.Errorf("content size %q has a min bigger than the max", spec)
+		}
+		dist.Min, dist.Max = min, max
+
+	case LogNormalContentSize:
+		medianStr, sigmaStr, ok := strings.Cut(args, ":")
+		if !ok {
+			return nil, errors.Errorf("content size %q doesn't follow lognormal:<median>:<sigma>", spec)
+		}
+		median, err := parseContentSize(medianStr)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("content size %q", spec))
+		}
+		sigma, err := strconv.ParseFloat(sigmaStr, 64)
+		if err != nil || sigma < 0 {
+			return nil, errors.Errorf("content size %q needs a positive sigma", spec)
+		}
+		dist.Median = float64(median)
+		dist.Sigma = sigma
+		dist.Min, dist.Max = 1, MaxContentSize
+
+	case HistogramContentSize:
+		bins, err := readContentSizeHistogram(args)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("content size %q", spec))
+		}
+		dist.Bins = bins
+		dist.Min, dist.Max = MaxContentSize, 1
+		for _, bin := range bins {
+			dist.totalCount += bin.Count
+			if bin.Min < dist.Min {
+				dist.Min = bin.Min
+			}
+			if bin.Max > dist.Max {
+				dist.Max = bin.Max
+			}
+		}
+
+	default:
+		return nil, errors.Errorf("content size %q has an unknown distribution %q [%s, %s, %s, %s]",
+			spec, mode, FixedContentSize, UniformContentSize, LogNormalContentSize, HistogramContentSize)
+	}
+	return dist, nil
+}
+
+// Sample returns the size of the next generated content. Fixed sizes don't consume the random generator,
+// so runs with a fixed size keep generating the same CIDs for the same seed
+func (d *ContentSizeDist) Sample(rng *rand.Rand) int {
+	switch d.Mode {
+	case UniformContentSize:
+		return d.Min + rng.Intn(d.Max-d.Min+1)
+	case LogNormalContentSize:
+		size := int(math.Round(d.Median * math.Exp(d.Sigma*rng.NormFloat64())))
+		if size < d.Min {
+			return d.Min
+		}
+		if size > d.Max {
+			return d.Max
+		}
+		return size
+	case HistogramContentSize:
+		pick := rng.Float64() * d.totalCount
+		bin := d.Bins[len(d.Bins)-1]
+		for _, b := range d.Bins {
+			if pick < b.Count {
+				bin = b
+				break
+			}
+			pick -= b.Count
+		}
+		return bin.Min + rng.Intn(bin.Max-bin.Min+1)
+	default:
+		return d.Min
+	}
+}
+
+func parseContentSize(s string) (int, error) {
+	size, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || size <= 0 || size > MaxContentSize {
+		return 0, errors.Errorf("size %q has to be between 1 and %d bytes", s, MaxContentSize)
+	}
+	return size, nil
+}
+
+// readContentSizeHistogram reads the bins of the histogram file, ignoring empty lines and lines starting with #
+func readContentSizeHistogram(path string) ([]ContentSizeBin, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	bins := make([]ContentSizeBin, 0)
+	var totalCount float64
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 3 {
+			return nil, errors.Errorf("line %d of %s doesn't follow <min> <max> <count>", line, path)
+		}
+		min, err := parseContentSize(fields[0])
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("line %d of %s", line, path))
+		}
+		max, err := parseContentSize(fields[1])
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("line %d of %s", line, path))
+		}
+		count, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil || count < 0 || min > max {
+			return nil, errors.Errorf("line %d of %s has an invalid bin", line, path)
+		}
+		totalCount += count
+		bins = append(bins, ContentSizeBin{Min: min, Max: max, Count: count})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if totalCount <= 0 {
+		return nil, errors.Errorf("histogram %s has no samples", path)
+	}
+	return bins, nil
+}
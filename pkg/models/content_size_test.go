@@ -0,0 +1,58 @@
+package models
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseContentSizeDist(t *testing.T) {
+	dist, err := ParseContentSizeDist("", 1024)
+	if err != nil || dist.Mode != FixedContentSize || dist.Min != 1024 {
+		t.Fatalf("empty dist should be the fixed size, got %v %v", dist, err)
+	}
+	invalid := []string{
+		"fixed:0",
+		"uniform:2048-1024",
+		"uniform:1024",
+		"lognormal:16384",
+		"lognormal:16384:-1",
+		"histogram:missing-file.txt",
+		"pareto:1",
+	}
+	for _, spec := range invalid {
+		if _, err := ParseContentSizeDist(spec, 1024); err == nil {
+			t.Fatalf("%s: expected error", spec)
+		}
+	}
+}
+
+func TestSampleContentSize(t *testing.T) {
+	histogram := filepath.Join(t.TempDir(), "sizes.txt")
+	content := "# min max count\n100 200 0\n1000 1000 5\n"
+	if err := os.WriteFile(histogram, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		spec     string
+		min, max int
+	}{
+		{"fixed:512", 512, 512},
+		{"uniform:10-20", 10, 20},
+		{"lognormal:4096:2", 1, MaxContentSize},
+		{"histogram:" + histogram, 1000, 1000},
+	}
+	rng := rand.New(rand.NewSource(42))
+	for _, c := range cases {
+		dist, err := ParseContentSizeDist(c.spec, 1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 100; i++ {
+			if size := dist.Sample(rng); size < c.min || size > c.max {
+				t.Fatalf("%s: sampled size %d out of [%d, %d]", c.spec, size, c.min, c.max)
+			}
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// GenerationParams gathers the seed and the parameters of the CidSource of a run,
+// which are enough to regenerate the exact same set of CIDs of the run
+type GenerationParams struct {
+	ID               int // assigned by the DB once persisted
+	StartTime        time.Time
+	Seed             int64
+	Source           string
+	CidFile          string
+	ContentSize      int
+	ContentSizeDist  string
+	CidNumber        int
+	CidPrefixes      []string
+	HashTarget       string
+	HashTargetBudget time.Duration
+	ImportPath       string
+	Chunker          string
+	DagLayout        string
+	ProvideStrategy  string
+	PingSchedule     string // spec of the schedule of the ping rounds of the CIDs
+}
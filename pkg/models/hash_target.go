@@ -0,0 +1,132 @@
+package models
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	cid "github.com/ipfs/go-cid"
+	kb "github.com/libp2p/go-libp2p-kbucket"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/pkg/errors"
+)
+
+// Supported modes to target the generation of CIDs to a region of the hash space
+const (
+	PrefixHashTarget     = "prefix"
+	BucketHashTarget     = "bucket"
+	StratifiedHashTarget = "stratified"
+)
+
+// MaxHashTargetBits limits the size of the targeted regions (1/2^MaxHashTargetBits of the hash space),
+// as the rejection sampling needs 2^bits CIDs on average to land a CID in the region
+const MaxHashTargetBits = 20
+
+// HashTarget defines the region of the DHT key space (sha256 of the multihash) where the generated CIDs have to land:
+//   - prefix:<bits>              the key starts with the given bits (i.e. "prefix:0110")
+//   - bucket:<peer-id>:<bucket>  the key falls in the given k-bucket of the peer (common prefix length with the peer)
+//   - stratified:<N>             the key space is split in N even regions, and CIDs are spread over them in turns
+type HashTarget struct {
+	Mode    string
+	Bits    string
+	Peer    peer.ID
+	Bucket  int
+	Regions int
+
+	peerKey kb.ID
+}
+
+// ParseHashTarget reads the hash target from its spec, returning nil if no target was given
+func ParseHashTarget(spec string) (*HashTarget, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+	mode, args, _ := strings.Cut(spec, ":")
+	target := &HashTarget{Mode: mode}
+	switch mode {
+	case PrefixHashTarget:
+		if len(args) == 0 || len(args) > MaxHashTargetBits || strings.Trim(args, "01") != "" {
+			return nil, errors.Errorf("hash target %q needs a prefix of 1 to %d bits (0s and 1s)", spec, MaxHashTargetBits)
+		}
+		target.Bits = args
+
+	case BucketHashTarget:
+		peerStr, bucketStr, ok := strings.Cut(args, ":")
+		if !ok {
+			return nil, errors.Errorf("hash target %q doesn't follow bucket:<peer-id>:<bucket>", spec)
+		}
+		p, err := peer.Decode(peerStr)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("hash target %q", spec))
+		}
+		bucket, err := strconv.Atoi(bucketStr)
+		// landing in bucket b requires b matching bits plus a different one
+		if err != nil || bucket < 0 || bucket >= MaxHashTargetBits {
+			return nil, errors.Errorf("hash target %q needs a bucket between 0 and %d", spec, MaxHashTargetBits-1)
+		}
+		target.Peer = p
+		target.Bucket = bucket
+		target.peerKey = kb.ConvertPeerID(p)
+
+	case StratifiedHashTarget:
+		regions, err := strconv.Atoi(args)
+		if err != nil || regions < 1 || regions > 1<<MaxHashTargetBits {
+			return nil, errors.Errorf("hash target %q needs between 1 and %d regions", spec, 1<<MaxHashTargetBits)
+		}
+		target.Regions = regions
+
+	default:
+		return nil, errors.Errorf("hash target %q has an unknown mode %q [%s, %s, %s]",
+			spec, mode, PrefixHashTarget, BucketHashTarget, StratifiedHashTarget)
+	}
+	return target, nil
+}
+
+// NumberOfRegions returns the number of regions that the target spreads the CIDs over
+func (t *HashTarget) NumberOfRegions() int {
+	if t.Mode == StratifiedHashTarget {
+		return t.Regions
+	}
+	return 1
+}
+
+// Matches checks whether the DHT key of the CID lands in the given region of the target
+func (t *HashTarget) Matches(c cid.Cid, region int) bool {
+	key := kb.ConvertKey(string(c.Hash()))
+	switch t.Mode {
+	case PrefixHashTarget:
+		for i := 0; i < len(t.Bits); i++ {
+			bit := (key[i/8] >> (7 - uint(i%8))) & 1
+			if bit != t.Bits[i]-'0' {
+				return false
+			}
+		}
+		return true
+	case BucketHashTarget:
+		return kb.CommonPrefixLen(t.peerKey, key) == t.Bucket
+	case StratifiedHashTarget:
+		// region = floor(key * N / 2^256)
+		keyInt := new(big.Int).SetBytes(key)
+		keyInt.Mul(keyInt, big.NewInt(int64(t.Regions)))
+		keyInt.Rsh(keyInt, uint(len(key)*8))
+		return keyInt.Int64() == int64(region)
+	default:
+		return false
+	}
+}
+
+// RegionString returns the description of the region that is persisted for each CID
+func (t *HashTarget) RegionString(region int) string {
+	switch t.Mode {
+	case PrefixHashTarget:
+		return PrefixHashTarget + ":" + t.Bits
+	case BucketHashTarget:
+		return fmt.Sprintf("%s:%s:%d", BucketHashTarget, t.Peer.String(), t.Bucket)
+	case StratifiedHashTarget:
+		return fmt.Sprintf("%s:%d/%d", StratifiedHashTarget, region, t.Regions)
+	default:
+		return ""
+	}
+}
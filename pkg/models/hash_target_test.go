@@ -0,0 +1,87 @@
+package models
+
+import (
+	"crypto/rand"
+	"testing"
+
+	cid "github.com/ipfs/go-cid"
+	kb "github.com/libp2p/go-libp2p-kbucket"
+	"github.com/libp2p/go-libp2p/core/test"
+	mh "github.com/multiformats/go-multihash"
+)
+
+func randomCid(t *testing.T) cid.Cid {
+	content := make([]byte, 64)
+	rand.Read(content)
+	hash, err := mh.Sum(content, mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cid.NewCidV1(cid.Raw, hash)
+}
+
+func TestParseHashTarget(t *testing.T) {
+	if target, err := ParseHashTarget(""); err != nil || target != nil {
+		t.Fatalf("empty target should be nil, got %v %v", target, err)
+	}
+	invalid := []string{
+		"prefix:",
+		"prefix:0102",
+		"prefix:111111111111111111111",
+		"bucket:not-a-peer:3",
+		"stratified:0",
+		"random:4",
+	}
+	for _, spec := range invalid {
+		if _, err := ParseHashTarget(spec); err == nil {
+			t.Fatalf("%s: expected error", spec)
+		}
+	}
+}
+
+func TestHashTargetMatches(t *testing.T) {
+	prefix, err := ParseHashTarget("prefix:101")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stratified, err := ParseHashTarget("stratified:4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// stratified regions of 4 are the same as the 2 bits prefixes
+	regionPrefixes := []string{"prefix:00", "prefix:01", "prefix:10", "prefix:11"}
+
+	for i := 0; i < 200; i++ {
+		c := randomCid(t)
+		key := kb.ConvertKey(string(c.Hash()))
+		expected := key[0]>>5 == 0b101
+		if prefix.Matches(c, 0) != expected {
+			t.Fatalf("prefix match of %x should be %t", key, expected)
+		}
+		for region, regionPrefix := range regionPrefixes {
+			target, _ := ParseHashTarget(regionPrefix)
+			if stratified.Matches(c, region) != target.Matches(c, 0) {
+				t.Fatalf("stratified region %d of %x doesn't match %s", region, key, regionPrefix)
+			}
+		}
+	}
+
+	p, err := test.RandPeerID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bucket, err := ParseHashTarget("bucket:" + p.String() + ":1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 200; i++ {
+		c := randomCid(t)
+		cpl := kb.CommonPrefixLen(kb.ConvertPeerID(p), kb.ConvertKey(string(c.Hash())))
+		if bucket.Matches(c, 0) != (cpl == 1) {
+			t.Fatalf("bucket match with cpl %d", cpl)
+		}
+	}
+	if region := stratified.RegionString(2); region != "stratified:2/4" {
+		t.Fatalf("wrong region string %s", region)
+	}
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"sync"
+	"time"
+
+	cid "github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// IpnsRecord is the signed IPNS record published next to a CID, pointing to it from a name of its own,
+// so that the liveness of IPNS records can be measured the same way as the one of the PRs
+type IpnsRecord struct {
+	m sync.RWMutex
+
+	Cid             cid.Cid
+	Name            peer.ID // peer ID of the key that signs the record
+	Key             string  // DHT key of the record (/ipns/<name>)
+	Value           []byte  // signed record, as it is sent in the PUT_VALUE messages
+	Sequence        uint64
+	EOL             time.Time // end of the validity of the record
+	PublishTime     time.Time
+	PublishDuration time.Duration
+	Error           string           // error of the PutValue operation (empty if none)
+	Holders         []*PeerInfo      // peers that got the PUT_VALUE of the record
+	PutResults      []*PRPingResults // result of the PUT_VALUE sent to each of the holders
+	Target          int
+	DoneC           chan struct{}
+}
+
+func NewIpnsRecord(c cid.Cid, name peer.ID, key string, value []byte, seq uint64, eol time.Time, k int) *IpnsRecord {
+	return &IpnsRecord{
+		Cid:        c,
+		Name:       name,
+		Key:        key,
+		Value:      value,
+		Sequence:   seq,
+		EOL:        eol,
+		Holders:    make([]*PeerInfo, 0, k),
+		PutResults: make([]*PRPingResults, 0, k),
+		Target:     k,
+		DoneC:      make(chan struct{}, 1),
+	}
+}
+
+// AddHolder tracks the result of the PUT_VALUE sent to one of the holders of the record
+func (r *IpnsRecord) AddHolder(holder *PeerInfo, putRes *PRPingResults) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.Holders = append(r.Holders, holder)
+	r.PutResults = append(r.PutResults, putRes)
+}
+
+// IsDone reports whether all the PUT_VALUE messages of the record have been tracked
+func (r *IpnsRecord) IsDone() bool {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	return len(r.PutResults) >= r.Target
+}
+
+// GetHolders returns a copy of the holders of the record
+func (r *IpnsRecord) GetHolders() []*PeerInfo {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	holders := make([]*PeerInfo, len(r.Holders))
+	copy(holders, r.Holders)
+	return holders
+}
+
+// GetSummary returns the summary of the PUT_VALUE messages of the record
+func (r *IpnsRecord) GetSummary() (tot, success, failed int) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	for _, putRes := range r.PutResults {
+		tot++
+		if putRes.Active {
+			success++
+		} else {
+			failed++
+		}
+	}
+	return tot, success, failed
+}
+
+// IpnsPingResult is the result of asking one of the holders of an IPNS record for it (GET_VALUE) on a ping round
+type IpnsPingResult struct {
+	Cid          cid.Cid
+	PeerID       peer.ID
+	Round        int
+	PingTime     time.Time
+	PingDuration time.Duration
+	Active       bool
+	HasRecord    bool
+	Sequence     int64 // sequence number of the returned record (-1 if none)
+	Valid        bool  // the returned record has a valid signature and hasn't expired
+	ConError     string
+}
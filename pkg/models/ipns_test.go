@@ -0,0 +1,33 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	cid "github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestIpnsRecordHolders(t *testing.T) {
+	record := NewIpnsRecord(cid.Undef, "name", "/ipns/name", nil, 0, time.Now(), 2)
+	if record.IsDone() {
+		t.Fatal("record without holders can't be done")
+	}
+	for i, active := range []bool{true, false} {
+		holder := peer.ID(rune('a' + i))
+		record.AddHolder(
+			NewPeerInfo(holder, nil, ""),
+			NewPRPingResults(cid.Undef, holder, 0, time.Now(), time.Now(), 0, active, false, false, ""),
+		)
+	}
+	if !record.IsDone() {
+		t.Fatal("record with all its holders should be done")
+	}
+	tot, success, failed := record.GetSummary()
+	if tot != 2 || success != 1 || failed != 1 {
+		t.Fatalf("expected 2 holders (1 successful, 1 failed), got %d (%d, %d)", tot, success, failed)
+	}
+	if len(record.GetHolders()) != 2 {
+		t.Fatalf("expected 2 holders, got %d", len(record.GetHolders()))
+	}
+}
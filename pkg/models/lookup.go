@@ -0,0 +1,90 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	cid "github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// Types of the DHT lookups that are traced
+const (
+	ProvideLookup      = "provide"
+	ClosestPeersLookup = "closest_peers"
+	ProvidersLookup    = "providers"
+)
+
+// LookupStep is each of the FIND_NODE (or GET_PROVIDERS) requests sent during a DHT lookup
+type LookupStep struct {
+	Peer              peer.ID
+	Hop               int     // 1 for the peers that came from the routing table
+	ReferredBy        peer.ID // peer that returned it in a previous step (empty for the ones from the routing table)
+	QueryTime         time.Time
+	QueryDuration     time.Duration
+	ReturnedPeers     []peer.ID
+	ReturnedProviders []peer.AddrInfo // providers returned by the GET_PROVIDERS requests
+	Error             string
+}
+
+func (s *LookupStep) responseTime() time.Time {
+	return s.QueryTime.Add(s.QueryDuration)
+}
+
+func (s *LookupStep) returned(p peer.ID) bool {
+	for _, rp := range s.ReturnedPeers {
+		if rp == p {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *LookupStep) returnedProvider(p peer.ID) bool {
+	for _, rp := range s.ReturnedProviders {
+		if rp.ID == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup is the graph of steps of a DHT lookup for the key of a CID. The round is the provide round
+// for the provide lookups, and the ping round for the closest peers lookups
+type Lookup struct {
+	Cid   cid.Cid
+	Round int
+	Type  string
+	Steps []*LookupStep
+}
+
+// NewLookup composes the lookup graph from its steps, sorting them by query time and linking each of them
+// to the first step that returned its peer before it was queried (which gives the hop of the step)
+func NewLookup(c cid.Cid, round int, lookupType string, steps []*LookupStep) *Lookup {
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].QueryTime.Before(steps[j].QueryTime)
+	})
+	for i, step := range steps {
+		var referrer *LookupStep
+		for _, prev := range steps[:i] {
+			if prev.responseTime().After(step.QueryTime) || !prev.returned(step.Peer) {
+				continue
+			}
+			if referrer == nil || prev.responseTime().Before(referrer.responseTime()) {
+				referrer = prev
+			}
+		}
+		step.Hop = 1
+		step.ReferredBy = ""
+		if referrer != nil {
+			step.Hop = referrer.Hop + 1
+			step.ReferredBy = referrer.Peer
+		}
+	}
+	return &Lookup{
+		Cid:   c,
+		Round: round,
+		Type:  lookupType,
+		Steps: steps,
+	}
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	cid "github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestLookupHops(t *testing.T) {
+	start := time.Now()
+	step := func(p peer.ID, startMs, durMs int, returned ...peer.ID) *LookupStep {
+		return &LookupStep{
+			Peer:          p,
+			QueryTime:     start.Add(time.Duration(startMs) * time.Millisecond),
+			QueryDuration: time.Duration(durMs) * time.Millisecond,
+			ReturnedPeers: returned,
+		}
+	}
+	// a and b come from the routing table, c is returned by both (b answers first),
+	// and d is returned by c, but also by a after d was already queried
+	lookup := NewLookup(cid.Undef, 1, ClosestPeersLookup, []*LookupStep{
+		step("d", 300, 10),
+		step("a", 0, 400, "c", "d"),
+		step("c", 150, 100, "d"),
+		step("b", 0, 100, "c"),
+	})
+
+	expected := map[peer.ID]struct {
+		hop        int
+		referredBy peer.ID
+	}{
+		"a": {1, ""},
+		"b": {1, ""},
+		"c": {2, "b"},
+		"d": {3, "c"},
+	}
+	for _, s := range lookup.Steps {
+		exp := expected[s.Peer]
+		if s.Hop != exp.hop || s.ReferredBy != exp.referredBy {
+			t.Fatalf("step %s: expected hop %d referred by %q, got %d %q", s.Peer, exp.hop, exp.referredBy, s.Hop, s.ReferredBy)
+		}
+	}
+	if lookup.Steps[len(lookup.Steps)-1].Peer != "d" {
+		t.Fatal("steps should be sorted by query time")
+	}
+}
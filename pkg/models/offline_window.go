@@ -0,0 +1,61 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// State of the creator of a CID on each of its rounds
+const (
+	CreatorOnline  = "online"
+	CreatorOffline = "offline"
+	CreatorUnknown = "unknown" // the creator isn't one of our hosts (i.e. discovered CIDs)
+)
+
+// OfflineWindow is a period of the study, given as offsets since its start, in which the
+// creator hosts are taken offline (they neither serve the content nor answer any connection)
+type OfflineWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// ParseOfflineWindows reads the offline windows from their spec "<start>-<end>,..." (e.g. "6h-12h,30h-31h"),
+// returning them sorted by their start. The windows can't overlap
+func ParseOfflineWindows(spec string) ([]OfflineWindow, error) {
+	windows := make([]OfflineWindow, 0)
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return windows, nil
+	}
+	for _, windowSpec := range strings.Split(spec, ",") {
+		startStr, endStr, ok := strings.Cut(strings.TrimSpace(windowSpec), "-")
+		if !ok {
+			return nil, errors.Errorf("offline window %q has to be <start>-<end>", windowSpec)
+		}
+		start, err := time.ParseDuration(startStr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "offline window %q", windowSpec)
+		}
+		end, err := time.ParseDuration(endStr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "offline window %q", windowSpec)
+		}
+		if start < 0 || end <= start {
+			return nil, errors.Errorf("offline window %q has to end after it starts", windowSpec)
+		}
+		windows = append(windows, OfflineWindow{start, end})
+	}
+	sort.Slice(windows, func(i, j int) bool {
+		return windows[i].Start < windows[j].Start
+	})
+	for i := 1; i < len(windows); i++ {
+		if windows[i].Start < windows[i-1].End {
+			return nil, errors.Errorf("offline windows %s-%s and %s-%s overlap",
+				windows[i-1].Start, windows[i-1].End, windows[i].Start, windows[i].End)
+		}
+	}
+	return windows, nil
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseOfflineWindows(t *testing.T) {
+	windows, err := ParseOfflineWindows("30h-31h, 6h-12h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(windows) != 2 || windows[0] != (OfflineWindow{6 * time.Hour, 12 * time.Hour}) || windows[1].Start != 30*time.Hour {
+		t.Fatalf("windows should be sorted by their start, got %v", windows)
+	}
+	invalid := []string{
+		"6h",
+		"6h-",
+		"12h-6h",
+		"-1h-2h",
+		"1h-3h,2h-4h",
+	}
+	for _, spec := range invalid {
+		if _, err := ParseOfflineWindows(spec); err == nil {
+			t.Fatalf("%s: expected error", spec)
+		}
+	}
+}
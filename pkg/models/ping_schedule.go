@@ -0,0 +1,169 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Supported schedules of the ping rounds of each CID
+const (
+	EveryPingSchedule   = "every"
+	StepsPingSchedule   = "steps"
+	ExpPingSchedule     = "exp"
+	OffsetsPingSchedule = "offsets"
+)
+
+// PingSchedule sets the offsets since the publication of a CID at which each of its ping rounds is planned:
+//   - every:<interval>                          a round every interval (the default, with the req-interval)
+//   - steps:<interval>/<span>,...,<interval>    a round every interval during each span, the last interval until the end
+//   - exp:<first>,<factor>[,<max>]              exponential backoff, each interval factor times the previous one (up to max)
+//   - offsets:<offset>,...                      explicit list of increasing offsets, no more rounds after the last one
+type PingSchedule struct {
+	Spec    string // normalized spec, as stored with the study
+	Mode    string
+	Steps   []PingStep
+	First   time.Duration
+	Factor  float64
+	Max     time.Duration // 0 if the intervals of the exponential backoff aren't capped
+	Offsets []time.Duration
+}
+
+// PingStep is a span of the study in which the CIDs are pinged at a constant interval
+type PingStep struct {
+	Interval time.Duration
+	Span     time.Duration // 0 for the last step (until the end of the study)
+}
+
+// ParsePingSchedule reads the ping schedule from its spec, which defaults to a round every
+// given req interval when the spec is empty
+func ParsePingSchedule(spec string, reqInterval time.Duration) (*PingSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		spec = fmt.Sprintf("%s:%s", EveryPingSchedule, reqInterval)
+	}
+	mode, args, _ := strings.Cut(spec, ":")
+	sched := &PingSchedule{Spec: spec, Mode: mode}
+	switch mode {
+	case EveryPingSchedule:
+		interval, err := parsePositiveDuration(args)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("ping schedule %q", spec))
+		}
+		sched.Steps = []PingStep{{Interval: interval}}
+
+	case StepsPingSchedule:
+		steps := strings.Split(args, ",")
+		for i, step := range steps {
+			intervalStr, spanStr, hasSpan := strings.Cut(step, "/")
+			if hasSpan == (i == len(steps)-1) {
+				return nil, errors.Errorf("ping schedule %q needs <interval>/<span> steps and a last <interval>", spec)
+			}
+			interval, err := parsePositiveDuration(intervalStr)
+			if err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("ping schedule %q", spec))
+			}
+			var span time.Duration
+			if hasSpan {
+				span, err = parsePositiveDuration(spanStr)
+				if err != nil {
+					return nil, errors.Wrap(err, fmt.Sprintf("ping schedule %q", spec))
+				}
+			}
+			sched.Steps = append(sched.Steps, PingStep{Interval: interval, Span: span})
+		}
+
+	case ExpPingSchedule:
+		params := strings.Split(args, ",")
+		if len(params) < 2 || len(params) > 3 {
+			return nil, errors.Errorf("ping schedule %q doesn't follow exp:<first>,<factor>[,<max>]", spec)
+		}
+		first, err := parsePositiveDuration(params[0])
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("ping schedule %q", spec))
+		}
+		factor, err := strconv.ParseFloat(strings.TrimSpace(params[1]), 64)
+		if err != nil || factor < 1 {
+			return nil, errors.Errorf("ping schedule %q needs a factor of at least 1", spec)
+		}
+		sched.First = first
+		sched.Factor = factor
+		if len(params) == 3 {
+			sched.Max, err = parsePositiveDuration(params[2])
+			if err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("ping schedule %q", spec))
+			}
+		}
+
+	case OffsetsPingSchedule:
+		var prev time.Duration
+		for _, offsetStr := range strings.Split(args, ",") {
+			offset, err := parsePositiveDuration(offsetStr)
+			if err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("ping schedule %q", spec))
+			}
+			if offset <= prev {
+				return nil, errors.Errorf("ping schedule %q needs increasing offsets", spec)
+			}
+			sched.Offsets = append(sched.Offsets, offset)
+			prev = offset
+		}
+
+	default:
+		return nil, errors.Errorf("ping schedule %q has an unknown mode %q [%s, %s, %s, %s]",
+			spec, mode, EveryPingSchedule, StepsPingSchedule, ExpPingSchedule, OffsetsPingSchedule)
+	}
+	return sched, nil
+}
+
+// Offset returns the offset since the publication of the CID at which the given ping round is planned
+// (starting from round 1), or false if the schedule has no such round
+func (s *PingSchedule) Offset(round int) (time.Duration, bool) {
+	if round <= 0 {
+		return 0, true
+	}
+	switch s.Mode {
+	case ExpPingSchedule:
+		var offset time.Duration
+		interval := float64(s.First)
+		for r := 1; r <= round; r++ {
+			if s.Max > 0 && interval > float64(s.Max) {
+				interval = float64(s.Max)
+			}
+			offset += time.Duration(interval)
+			interval *= s.Factor
+		}
+		return offset, true
+
+	case OffsetsPingSchedule:
+		if round > len(s.Offsets) {
+			return 0, false
+		}
+		return s.Offsets[round-1], true
+
+	default:
+		var offset, stepStart time.Duration
+		step := 0
+		for r := 1; r <= round; r++ {
+			// move to the next step once the span of the current one is covered
+			for s.Steps[step].Span > 0 && offset+s.Steps[step].Interval > stepStart+s.Steps[step].Span {
+				stepStart += s.Steps[step].Span
+				offset = stepStart
+				step++
+			}
+			offset += s.Steps[step].Interval
+		}
+		return offset, true
+	}
+}
+
+func parsePositiveDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(strings.TrimSpace(s))
+	if err != nil || d <= 0 {
+		return 0, errors.Errorf("%q is not a positive duration (example '1m', '1h')", s)
+	}
+	return d, nil
+}
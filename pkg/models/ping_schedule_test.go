@@ -0,0 +1,55 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePingSchedule(t *testing.T) {
+	sched, err := ParsePingSchedule("", 30*time.Minute)
+	if err != nil || sched.Mode != EveryPingSchedule || sched.Spec != "every:30m0s" {
+		t.Fatalf("empty schedule should be a round every req interval, got %v %v", sched, err)
+	}
+	invalid := []string{
+		"every:0s",
+		"steps:1m/10m",
+		"steps:1m,1h",
+		"exp:1m",
+		"exp:1m,0.5",
+		"offsets:10m,5m",
+		"hourly",
+	}
+	for _, spec := range invalid {
+		if _, err := ParsePingSchedule(spec, time.Minute); err == nil {
+			t.Fatalf("%s: expected error", spec)
+		}
+	}
+}
+
+func TestPingScheduleOffsets(t *testing.T) {
+	expected := map[string][]time.Duration{
+		"every:30m":              {0, 30 * time.Minute, time.Hour, 90 * time.Minute},
+		"steps:5m/10m,10m/1h,1h": {0, 5 * time.Minute, 10 * time.Minute, 20 * time.Minute, 30 * time.Minute},
+		"exp:1m,2,3m":            {0, time.Minute, 3 * time.Minute, 6 * time.Minute, 9 * time.Minute},
+		"offsets:5m,1h,6h":       {0, 5 * time.Minute, time.Hour, 6 * time.Hour},
+		"steps:1m/2m,1h/2h,1h":   {0, time.Minute, 2 * time.Minute, 62 * time.Minute, 122 * time.Minute, 182 * time.Minute},
+	}
+	for spec, offsets := range expected {
+		sched, err := ParsePingSchedule(spec, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for round, expectedOffset := range offsets {
+			offset, ok := sched.Offset(round)
+			if !ok || offset != expectedOffset {
+				t.Fatalf("%s: round %d expected at %s, got %s (%v)", spec, round, expectedOffset, offset, ok)
+			}
+		}
+	}
+
+	// no more rounds after the last explicit offset
+	sched, _ := ParsePingSchedule("offsets:5m,1h,6h", time.Minute)
+	if _, ok := sched.Offset(4); ok {
+		t.Fatal("offsets: expected no round after the last offset")
+	}
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"sync"
+	"time"
+
+	cid "github.com/ipfs/go-cid"
+)
+
+// ProvideEvent is each of the times that the PRs of a CID are provided to the network:
+// the initial publication (provide round 0) and each of the following republishes
+type ProvideEvent struct {
+	m sync.RWMutex
+
+	Cid             cid.Cid
+	Round           int       // provide round (0 for the initial publication)
+	PingRound       int       // ping rounds already done when the CID was provided
+	Attempt         int       // attempt of the provide round (1 unless the provide had to be retried)
+	ProvideTime     time.Time // when the provide operation started
+	ProvideDuration time.Duration
+	Error           string           // error of the provide operation (empty if none)
+	PRHolders       []*PeerInfo      // peers that got the ADD_PROVIDER of this provide round
+	NewPRHolders    int              // PR Holders that weren't holding the PRs of the CID before this round
+	Results         *CidFetchResults // result of the ADD_PROVIDER sent to each of the PR Holders
+}
+
+// NewProvideEvent composes the provide event of the given round, starting it at the given time
+func NewProvideEvent(c cid.Cid, round, pingRound int, provideTime time.Time, k int) *ProvideEvent {
+	return &ProvideEvent{
+		Cid:         c,
+		Round:       round,
+		PingRound:   pingRound,
+		Attempt:     1,
+		ProvideTime: provideTime,
+		PRHolders:   make([]*PeerInfo, 0, k),
+		Results:     NewCidFetchResults(c, provideTime, pingRound, k),
+	}
+}
+
+// Retry composes the next attempt of the same provide round, starting it at the given time
+func (e *ProvideEvent) Retry(provideTime time.Time, k int) *ProvideEvent {
+	retry := NewProvideEvent(e.Cid, e.Round, e.PingRound, provideTime, k)
+	retry.Attempt = e.Attempt + 1
+	return retry
+}
+
+// AddPRHolder adds a peer that got the ADD_PROVIDER of the provide round,
+// flagging whether it wasn't holding the PRs of the CID before
+func (e *ProvideEvent) AddPRHolder(prHolder *PeerInfo, isNew bool) {
+	e.m.Lock()
+	defer e.m.Unlock()
+	e.PRHolders = append(e.PRHolders, prHolder)
+	if isNew {
+		e.NewPRHolders++
+	}
+}
+
+// GetPRHolders returns a copy of the PR Holders of the provide round
+func (e *ProvideEvent) GetPRHolders() []*PeerInfo {
+	e.m.RLock()
+	defer e.m.RUnlock()
+	prHolders := make([]*PeerInfo, len(e.PRHolders))
+	copy(prHolders, e.PRHolders)
+	return prHolders
+}
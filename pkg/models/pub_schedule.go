@@ -0,0 +1,208 @@
+package models
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Supported schedules to hand out the publication slots to the publishers
+const (
+	ConstantPubSchedule = "constant"
+	PoissonPubSchedule  = "poisson"
+	BurstPubSchedule    = "burst"
+	DailyPubSchedule    = "daily"
+)
+
+const day = 24 * time.Hour
+
+// PubSchedule is the arrival pattern of the publications of the whole publisher (not of each worker):
+//   - constant:<n>/<period>                     n publications evenly spaced over each period
+//   - poisson:<n>/<period>                      Poisson arrivals with a mean of n publications per period
+//   - burst:<n>/<period>                        n publications at once at the end of each period
+//   - daily:<hh:mm>-<hh:mm>=<n>/<period>,...    constant rate on each time-of-day window (UTC), no publications outside them
+type PubSchedule struct {
+	Mode    string
+	Rate    float64 // publications per second (constant and poisson)
+	Burst   int
+	Period  time.Duration // time between bursts
+	Windows []PubWindow
+}
+
+// PubWindow is a time-of-day window of a daily schedule, that wraps around midnight if it ends before it starts
+type PubWindow struct {
+	Start time.Duration // since midnight UTC
+	End   time.Duration
+	Rate  float64 // publications per second
+}
+
+func (w PubWindow) contains(tod time.Duration) bool {
+	if w.Start <= w.End {
+		return tod >= w.Start && tod < w.End
+	}
+	return tod >= w.Start || tod < w.End
+}
+
+// ParsePubSchedule reads the publication schedule from its spec, which defaults to a constant
+// rate of the given publications per second when the spec is empty
+func ParsePubSchedule(spec string, defaultRate float64) (*PubSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return &PubSchedule{Mode: ConstantPubSchedule, Rate: defaultRate}, nil
+	}
+	mode, args, _ := strings.Cut(spec, ":")
+	sched := &PubSchedule{Mode: mode}
+	switch mode {
+	case ConstantPubSchedule, PoissonPubSchedule:
+		n, period, err := parsePubRate(args)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("pub schedule %q", spec))
+		}
+		sched.Rate = float64(n) / period.Seconds()
+
+	case BurstPubSchedule:
+		n, period, err := parsePubRate(args)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("pub schedule %q", spec))
+		}
+		sched.Burst = n
+		sched.Period = period
+
+	case DailyPubSchedule:
+		for _, w := range strings.Split(args, ",") {
+			window, rate, ok := strings.Cut(w, "=")
+			if !ok {
+				return nil, errors.Errorf("pub schedule %q window %q doesn't follow <hh:mm>-<hh:mm>=<n>/<period>", spec, w)
+			}
+			startStr, endStr, ok := strings.Cut(window, "-")
+			if !ok {
+				return nil, errors.Errorf("pub schedule %q window %q doesn't follow <hh:mm>-<hh:mm>", spec, window)
+			}
+			start, err := parseTimeOfDay(startStr)
+			if err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("pub schedule %q", spec))
+			}
+			end, err := parseTimeOfDay(endStr)
+			if err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("pub schedule %q", spec))
+			}
+			if start == end {
+				return nil, errors.Errorf("pub schedule %q has an empty window %q", spec, window)
+			}
+			n, period, err := parsePubRate(rate)
+			if err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("pub schedule %q", spec))
+			}
+			sched.Windows = append(sched.Windows, PubWindow{Start: start, End: end, Rate: float64(n) / period.Seconds()})
+		}
+
+	default:
+		return nil, errors.Errorf("pub schedule %q has an unknown mode %q [%s, %s, %s, %s]",
+			spec, mode, ConstantPubSchedule, PoissonPubSchedule, BurstPubSchedule, DailyPubSchedule)
+	}
+	return sched, nil
+}
+
+// Next returns the time to wait from the given moment until the next publication slots are released,
+// and the number of slots released then (0 if the schedule has to be checked again after the wait)
+func (s *PubSchedule) Next(now time.Time, rng *rand.Rand) (time.Duration, int) {
+	switch s.Mode {
+	case PoissonPubSchedule:
+		return secsToDuration(rng.ExpFloat64() / s.Rate), 1
+	case BurstPubSchedule:
+		return s.Period, s.Burst
+	case DailyPubSchedule:
+		if window, ok := s.windowAt(now); ok {
+			wait := secsToDuration(1 / window.Rate)
+			if _, ok := s.windowAt(now.Add(wait)); ok {
+				return wait, 1
+			}
+		}
+		return s.untilNextWindow(now), 0
+	default:
+		return secsToDuration(1 / s.Rate), 1
+	}
+}
+
+// TargetRate returns the publications per second that the schedule aims for at the given moment
+func (s *PubSchedule) TargetRate(now time.Time) float64 {
+	switch s.Mode {
+	case BurstPubSchedule:
+		return float64(s.Burst) / s.Period.Seconds()
+	case DailyPubSchedule:
+		if window, ok := s.windowAt(now); ok {
+			return window.Rate
+		}
+		return 0
+	default:
+		return s.Rate
+	}
+}
+
+func (s *PubSchedule) windowAt(t time.Time) (PubWindow, bool) {
+	tod := timeOfDay(t)
+	for _, w := range s.Windows {
+		if w.contains(tod) {
+			return w, true
+		}
+	}
+	return PubWindow{}, false
+}
+
+// untilNextWindow returns the time left until the start of the closest window
+func (s *PubSchedule) untilNextWindow(now time.Time) time.Duration {
+	tod := timeOfDay(now)
+	next := day
+	for _, w := range s.Windows {
+		wait := (w.Start - tod + day) % day
+		if wait == 0 {
+			wait = day
+		}
+		if wait < next {
+			next = wait
+		}
+	}
+	return next
+}
+
+func timeOfDay(t time.Time) time.Duration {
+	t = t.UTC()
+	return t.Sub(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC))
+}
+
+func secsToDuration(secs float64) time.Duration {
+	return time.Duration(secs * float64(time.Second))
+}
+
+// parsePubRate reads a "<n>/<period>" rate, where the period is a Go duration ("10/1m", "500/1h")
+func parsePubRate(s string) (int, time.Duration, error) {
+	nStr, periodStr, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return 0, 0, errors.Errorf("rate %q doesn't follow <n>/<period>", s)
+	}
+	n, err := strconv.Atoi(nStr)
+	if err != nil || n <= 0 {
+		return 0, 0, errors.Errorf("rate %q needs a positive number of publications", s)
+	}
+	period, err := time.ParseDuration(periodStr)
+	if err != nil || period <= 0 {
+		return 0, 0, errors.Errorf("rate %q needs a positive period (example '1m', '1h')", s)
+	}
+	return n, period, nil
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		// allow closing a window at midnight
+		if strings.TrimSpace(s) == "24:00" {
+			return day, nil
+		}
+		return 0, errors.Errorf("time of day %q doesn't follow <hh:mm>", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
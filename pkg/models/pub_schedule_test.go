@@ -0,0 +1,66 @@
+package models
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestParsePubSchedule(t *testing.T) {
+	sched, err := ParsePubSchedule("", 0.5)
+	if err != nil || sched.Mode != ConstantPubSchedule || sched.Rate != 0.5 {
+		t.Fatalf("empty schedule should be the default constant rate, got %v %v", sched, err)
+	}
+	invalid := []string{
+		"constant:10",
+		"poisson:0/1m",
+		"burst:10/-1m",
+		"daily:08:00-08:00=1/1m",
+		"daily:08:00=1/1m",
+		"daily:8am-9am=1/1m",
+		"linear:1/1m",
+	}
+	for _, spec := range invalid {
+		if _, err := ParsePubSchedule(spec, 1); err == nil {
+			t.Fatalf("%s: expected error", spec)
+		}
+	}
+}
+
+func TestNextPubSlot(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	noon := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	constant, _ := ParsePubSchedule("constant:2/1m", 0)
+	if wait, slots := constant.Next(noon, rng); wait != 30*time.Second || slots != 1 {
+		t.Fatalf("constant: got %s %d", wait, slots)
+	}
+
+	burst, _ := ParsePubSchedule("burst:50/10m", 0)
+	if wait, slots := burst.Next(noon, rng); wait != 10*time.Minute || slots != 50 {
+		t.Fatalf("burst: got %s %d", wait, slots)
+	}
+
+	// the mean of the poisson inter-arrival times has to be close to the rate
+	poisson, _ := ParsePubSchedule("poisson:60/1m", 0)
+	var total time.Duration
+	for i := 0; i < 10000; i++ {
+		wait, _ := poisson.Next(noon, rng)
+		total += wait
+	}
+	if mean := total / 10000; mean < 900*time.Millisecond || mean > 1100*time.Millisecond {
+		t.Fatalf("poisson: mean inter-arrival %s far from 1s", mean)
+	}
+
+	// windows wrap around midnight, and nothing is published outside them
+	daily, _ := ParsePubSchedule("daily:22:00-02:00=1/1m,08:00-09:00=4/1m", 0)
+	if wait, slots := daily.Next(noon, rng); wait != 10*time.Hour || slots != 0 {
+		t.Fatalf("daily outside windows: got %s %d", wait, slots)
+	}
+	if wait, slots := daily.Next(noon.Add(11*time.Hour+30*time.Minute), rng); wait != time.Minute || slots != 1 {
+		t.Fatalf("daily at 23:30: got %s %d", wait, slots)
+	}
+	if rate := daily.TargetRate(noon.Add(-3*time.Hour - 30*time.Minute)); rate != 4.0/60 {
+		t.Fatalf("daily at 08:30: got rate %f", rate)
+	}
+}
@@ -0,0 +1,82 @@
+package p2p
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ipfs/boxo/routing/http/client"
+	"github.com/ipfs/boxo/routing/http/contentrouter"
+	"github.com/ipfs/boxo/routing/http/types"
+	cid "github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// DelegatedRoutingTTL is the advisory TTL of the provider records sent to the delegated routing endpoint
+// (as the expiration of the PRs in the DHT)
+const DelegatedRoutingTTL = 48 * time.Hour
+
+// DelegatedRouter provides and looks for the providers of the CIDs through a delegated
+// routing HTTP endpoint (/routing/v1), as an alternative routing system to the DHT
+type DelegatedRouter struct {
+	endpoint string
+	client   contentrouter.Client
+}
+
+// NewDelegatedRouter returns a client of the given delegated routing endpoint, which signs
+// the provider records with the given identity, announcing the given multiaddresses
+func NewDelegatedRouter(endpoint string, privKey crypto.PrivKey, maddrs []ma.Multiaddr) (*DelegatedRouter, error) {
+	pid, err := peer.IDFromPrivateKey(privKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to compose the provider ID")
+	}
+	cli, err := client.New(
+		endpoint,
+		client.WithIdentity(privKey),
+		client.WithProviderInfo(pid, maddrs),
+		client.WithUserAgent(DefaultUserAgent),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create the delegated routing client")
+	}
+	return &DelegatedRouter{
+		endpoint: endpoint,
+		client:   cli,
+	}, nil
+}
+
+// GetEndpoint returns the URL of the delegated routing endpoint
+func (r *DelegatedRouter) GetEndpoint() string {
+	return r.endpoint
+}
+
+// Provide sends a signed Bitswap provider record of the CID to the endpoint (PUT /routing/v1/providers/)
+func (r *DelegatedRouter) Provide(ctx context.Context, c cid.Cid) (time.Duration, error) {
+	startT := time.Now()
+	_, err := r.client.ProvideBitswap(ctx, []cid.Cid{c}, DelegatedRoutingTTL)
+	return time.Since(startT), err
+}
+
+// FindProviders asks the endpoint for the providers of the CID (GET /routing/v1/providers/{cid}),
+// returning only the Bitswap ones, in the order they were returned
+func (r *DelegatedRouter) FindProviders(ctx context.Context, c cid.Cid) (time.Duration, []peer.AddrInfo, error) {
+	startT := time.Now()
+	resp, err := r.client.FindProviders(ctx, c)
+	duration := time.Since(startT)
+	providers := make([]peer.AddrInfo, 0, len(resp))
+	for _, prov := range resp {
+		bsProv, ok := prov.(*types.ReadBitswapProviderRecord)
+		if !ok || bsProv.ID == nil {
+			continue
+		}
+		addrInfo := peer.AddrInfo{ID: *bsProv.ID}
+		for _, maddr := range bsProv.Addrs {
+			addrInfo.Addrs = append(addrInfo.Addrs, maddr.Multiaddr)
+		}
+		providers = append(providers, addrInfo)
+	}
+	return duration, providers, err
+}
@@ -0,0 +1,109 @@
+package p2p
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipfs/boxo/routing/http/server"
+	"github.com/ipfs/boxo/routing/http/types"
+	cid "github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	mh "github.com/multiformats/go-multihash"
+)
+
+// standInRouter is a minimal delegated routing endpoint that keeps the Bitswap provider records in memory
+type standInRouter struct {
+	m         sync.Mutex
+	providers map[cid.Cid][]*types.ReadBitswapProviderRecord
+}
+
+func (r *standInRouter) FindProviders(_ context.Context, key cid.Cid) ([]types.ProviderResponse, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	resp := make([]types.ProviderResponse, 0, len(r.providers[key]))
+	for _, prov := range r.providers[key] {
+		resp = append(resp, prov)
+	}
+	return resp, nil
+}
+
+func (r *standInRouter) ProvideBitswap(_ context.Context, req *server.BitswapWriteProvideRequest) (time.Duration, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	id := req.ID
+	record := &types.ReadBitswapProviderRecord{
+		Protocol: "transport-bitswap",
+		Schema:   types.SchemaBitswap,
+		ID:       &id,
+	}
+	for _, maddr := range req.Addrs {
+		record.Addrs = append(record.Addrs, types.Multiaddr{Multiaddr: maddr})
+	}
+	for _, key := range req.Keys {
+		r.providers[key] = append(r.providers[key], record)
+	}
+	return req.AdvisoryTTL, nil
+}
+
+func (r *standInRouter) Provide(context.Context, *server.WriteProvideRequest) (types.ProviderResponse, error) {
+	return nil, nil
+}
+
+func TestDelegatedRouter(t *testing.T) {
+	standIn := &standInRouter{providers: make(map[cid.Cid][]*types.ReadBitswapProviderRecord)}
+	srv := httptest.NewServer(server.Handler(standIn))
+	defer srv.Close()
+
+	privKey, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := peer.IDFromPrivateKey(privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	maddr, err := ma.NewMultiaddr("/ip4/127.0.0.1/tcp/4001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	router, err := NewDelegatedRouter(srv.URL, privKey, []ma.Multiaddr{maddr})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := mh.Sum([]byte("delegated routing"), mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := cid.NewCidV1(cid.Raw, hash)
+	ctx := context.Background()
+
+	// nobody provided the CID yet
+	_, providers, err := router.FindProviders(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(providers) != 0 {
+		t.Fatalf("expected no providers before the provide, got %d", len(providers))
+	}
+
+	if _, err := router.Provide(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	_, providers, err = router.FindProviders(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(providers) != 1 || providers[0].ID != pid {
+		t.Fatalf("expected %s as the only provider, got %v", pid, providers)
+	}
+	if len(providers[0].Addrs) != 1 || !providers[0].Addrs[0].Equal(maddr) {
+		t.Fatalf("expected %s as the address of the provider, got %v", maddr, providers[0].Addrs)
+	}
+}
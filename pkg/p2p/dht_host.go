@@ -12,18 +12,27 @@ import (
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/ipfs/boxo/bitswap"
+	bsnet "github.com/ipfs/boxo/bitswap/network"
+	"github.com/ipfs/boxo/blockstore"
 	cid "github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
 	ma "github.com/multiformats/go-multiaddr"
+	mh "github.com/multiformats/go-multihash"
 
 	"github.com/libp2p/go-libp2p-xor/key"
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
 	"github.com/libp2p/go-libp2p/core/routing"
 
 	libp2p "github.com/libp2p/go-libp2p"
 	kaddht "github.com/libp2p/go-libp2p-kad-dht"
+	"github.com/libp2p/go-libp2p-kad-dht/fullrt"
+	pb "github.com/libp2p/go-libp2p-kad-dht/pb"
 	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
 	quic "github.com/libp2p/go-libp2p/p2p/transport/quic"
 	"github.com/libp2p/go-libp2p/p2p/transport/tcp"
@@ -31,6 +40,13 @@ import (
 
 type ProvideOption string
 
+var (
+	ErrorBitswapDisabled = errors.New("bitswap is not enabled in the host")
+	ErrorNoFullRT        = errors.New("the host has no accelerated DHT client")
+	// the block was fetched, but it was sent by another peer than the provider
+	ErrorFetchedFromOtherPeer = errors.New("block sent by another peer than the provider")
+)
+
 func GetProvOpFromConf(strOp string) ProvideOption {
 	provOp := StandardDHTProvide // Default
 	switch strOp {
@@ -38,6 +54,8 @@ func GetProvOpFromConf(strOp string) ProvideOption {
 		provOp = StandardDHTProvide
 	case "optimistic":
 		provOp = OpDHTProvide
+	case "fullrt":
+		provOp = FullRTProvide
 	}
 	return provOp
 }
@@ -45,11 +63,14 @@ func GetProvOpFromConf(strOp string) ProvideOption {
 var (
 	StandardDHTProvide ProvideOption = "std-dht-provide"
 	OpDHTProvide       ProvideOption = "op-provide"
+	FullRTProvide      ProvideOption = "fullrt-provide"
 
 	DefaultUserAgent string = "cid-hoarder"
 	PingGraceTime           = 5 * time.Second
 	MaxDialAttempts         = 1
 	DialTimeout             = 60 * time.Second
+	// the accelerated client needs to crawl the whole network before it can provide
+	FullRTReadyTimeout = 15 * time.Minute
 )
 
 type DHTHostOptions struct {
@@ -61,6 +82,11 @@ type DHTHostOptions struct {
 	K                int
 	BlacklistingUA   string
 	BlacklistedPeers map[peer.ID]struct{}
+	// Bitswap exchange to serve and fetch the content of the CIDs
+	WithBitswap bool
+	Blockstore  blockstore.Blockstore // content served by the host (an empty one is used if nil)
+	// delegated routing HTTP endpoint to compare against the DHT (none if empty)
+	DelegatedRouting string
 }
 
 // DHT Host is the main operational instance to communicate with the IPFS DHT
@@ -70,9 +96,18 @@ type DHTHost struct {
 	// host related
 	id                  int
 	dht                 *kaddht.IpfsDHT
+	fullRT              *fullrt.FullRT        // accelerated DHT client (only for the fullrt provide operation)
+	protoMessenger      *pb.ProtocolMessenger // to send single DHT requests (i.e. GET_VALUE) to a peer
+	delegatedRouter     *DelegatedRouter      // delegated routing HTTP client (nil if there is no endpoint)
 	host                host.Host
 	internalMsgNotifier *MsgNotifier
+	lookupTracer        *LookupTracer
+	gater               *onlineGater // takes the host offline
 	initTime            time.Time
+	// content exchange related
+	bitswap     *bitswap.Bitswap
+	blockstore  blockstore.Blockstore
+	fetchTracer *fetchTracer
 	// dht query related
 	ongoingPings map[cid.Cid]struct{}
 }
@@ -108,6 +143,7 @@ func NewDHTHost(ctx context.Context, opts DHTHostOptions) (*DHTHost, error) {
 	}
 
 	// generate the libp2p host
+	gater := &onlineGater{}
 	h, err := libp2p.New(
 		libp2p.WithDialTimeout(DialTimeout),
 		libp2p.ListenAddrs(mAddrs...),
@@ -117,6 +153,7 @@ func NewDHTHost(ctx context.Context, opts DHTHostOptions) (*DHTHost, error) {
 		libp2p.Transport(tcp.NewTCPTransport),
 		libp2p.Transport(quic.NewTransport),
 		libp2p.DialRanker(CustomDialRanker),
+		libp2p.ConnectionGater(gater),
 		libp2p.Routing(func(h host.Host) (routing.PeerRouting, error) {
 			var err error
 			dhtOpts := make([]kaddht.Option, 0)
@@ -139,14 +176,64 @@ func NewDHTHost(ctx context.Context, opts DHTHostOptions) (*DHTHost, error) {
 		return nil, errors.New("no IpfsDHT client was able to be created")
 	}
 
+	// accelerated DHT client sharing the msg sender (already initialized by the DHT client),
+	// so its ADD_PROVIDER messages are notified as well
+	var fullRT *fullrt.FullRT
+	if opts.ProvOp == FullRTProvide {
+		fullRT, err = fullrt.NewFullRT(h, protocol.ID("/ipfs"),
+			fullrt.DHTOption(
+				kaddht.WithCustomMessageSender(func(host.Host, []protocol.ID) pb.MessageSender {
+					return msgSender
+				}),
+				kaddht.BucketSize(opts.K),
+				kaddht.WithPeerBlacklist(opts.BlacklistedPeers)))
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to create the accelerated DHT client")
+		}
+	}
+
+	protoMessenger, err := pb.NewProtocolMessenger(msgSender)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create the DHT protocol messenger")
+	}
+
+	var delegatedRouter *DelegatedRouter
+	if opts.DelegatedRouting != "" {
+		delegatedRouter, err = NewDelegatedRouter(opts.DelegatedRouting, privKey, h.Addrs())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// bitswap exchange (only announcing the content through the DHT provide operation of the hoarder)
+	var bswap *bitswap.Bitswap
+	bstore := opts.Blockstore
+	fetchTracer := newFetchTracer()
+	if opts.WithBitswap {
+		if bstore == nil {
+			bstore = blockstore.NewBlockstore(dssync.MutexWrap(ds.NewMapDatastore()))
+		}
+		bswap = bitswap.New(ctx, bsnet.NewFromIpfsHost(h, dht), bstore,
+			bitswap.ProvideEnabled(false),
+			bitswap.WithTracer(fetchTracer))
+	}
+
 	dhtHost := &DHTHost{
 		ctx,
 		sync.RWMutex{},
 		opts.ID,
 		dht,
+		fullRT,
+		protoMessenger,
+		delegatedRouter,
 		h,
 		msgSender.GetMsgNotifier(),
+		msgSender.GetLookupTracer(),
+		gater,
 		time.Now(),
+		bswap,
+		bstore,
+		fetchTracer,
 		make(map[cid.Cid]struct{}),
 	}
 
@@ -203,6 +290,24 @@ func (h *DHTHost) GetHostID() int {
 	return h.id
 }
 
+// SetOnline takes the host online or offline. An offline host closes all its connections
+// and rejects any new one until it is online again
+func (h *DHTHost) SetOnline(online bool) {
+	h.gater.offline.Store(!online)
+	if online {
+		return
+	}
+	for _, conn := range h.host.Network().Conns() {
+		if err := conn.Close(); err != nil {
+			log.WithField("host-id", h.id).Tracef("unable to close connection to %s - %s", conn.RemotePeer(), err.Error())
+		}
+	}
+}
+
+func (h *DHTHost) IsOnline() bool {
+	return !h.gater.offline.Load()
+}
+
 func (h *DHTHost) GetMsgNotifier() *MsgNotifier {
 	return h.internalMsgNotifier
 }
@@ -285,6 +390,28 @@ func (h *DHTHost) PingPRHolderOnCid(
 	remotePeer peer.AddrInfo,
 	cid *models.CidInfo) *models.PRPingResults {
 
+	return h.pingHolderOnCid(ctx, remotePeer, cid, cid.IsCreator)
+}
+
+// PingProviderHolderOnCid pings one of the PR Holders of an extra provider of the CID,
+// checking whether it keeps the records of that provider
+func (h *DHTHost) PingProviderHolderOnCid(
+	ctx context.Context,
+	remotePeer peer.AddrInfo,
+	cid *models.CidInfo,
+	provider peer.ID) *models.PRPingResults {
+
+	return h.pingHolderOnCid(ctx, remotePeer, cid, func(p peer.ID) bool { return p == provider })
+}
+
+// pingHolderOnCid asks the remote peer for the providers of the CID, checking whether any of
+// the returned ones is a tracked provider
+func (h *DHTHost) pingHolderOnCid(
+	ctx context.Context,
+	remotePeer peer.AddrInfo,
+	cid *models.CidInfo,
+	isTracked func(peer.ID) bool) *models.PRPingResults {
+
 	hlog := log.WithFields(log.Fields{
 		"host-id":   h.id,
 		"cid":       cid.CID.Hash().B58String(),
@@ -293,7 +420,7 @@ func (h *DHTHost) PingPRHolderOnCid(
 
 	ctx = network.WithForceDirectDial(ctx, "prevent backoff")
 	var active, hasRecords, recordsWithMAddrs bool
-	var connError string = DialErrorUnknown
+	var connError string
 	tstart := time.Now()
 
 	// fulfill the control fields from a successful connection
@@ -309,7 +436,7 @@ func (h *DHTHost) PingPRHolderOnCid(
 		}
 
 		for _, provider := range providers {
-			if provider.ID == cid.Creator {
+			if isTracked(provider.ID) {
 				hasRecords = true
 				if len(provider.Addrs) > 0 {
 					recordsWithMAddrs = true
@@ -323,6 +450,28 @@ func (h *DHTHost) PingPRHolderOnCid(
 		}
 	}
 	// check if the peer is among the already connected ones
+	connError = h.connectPeer(ctx, hlog, remotePeer)
+	if connError == NoConnError {
+		succesfulConnection()
+	}
+
+	return models.NewPRPingResults(
+		cid.CID,
+		remotePeer.ID,
+		-1, // caller will need to update the Round with the given idx
+		cid.PublishTime,
+		tstart,
+		time.Since(tstart),
+		active,
+		hasRecords,
+		recordsWithMAddrs,
+		connError)
+}
+
+// connectPeer connects the remote peer, retrying the connections that were refused or reset,
+// and returns the parsed connection error (NoConnError if the connection succeeded)
+func (h *DHTHost) connectPeer(ctx context.Context, hlog *log.Entry, remotePeer peer.AddrInfo) string {
+	connError := DialErrorUnknown
 	// loop over max tries if the connection is connection refused/ connection reset by peer
 connetionRetry:
 	for att := 0; att < MaxDialAttempts; att++ {
@@ -332,7 +481,6 @@ connetionRetry:
 		switch connError {
 		case NoConnError: // no error at all
 			hlog.Debugf("succesful connection")
-			succesfulConnection()
 			break connetionRetry
 
 		case DialErrorConnectionRefused, DialErrorStreamReset:
@@ -356,18 +504,19 @@ connetionRetry:
 			break connetionRetry
 		}
 	}
+	return connError
+}
 
-	return models.NewPRPingResults(
-		cid.CID,
-		remotePeer.ID,
-		-1, // caller will need to update the Round with the given idx
-		cid.PublishTime,
-		tstart,
-		time.Since(tstart),
-		active,
-		hasRecords,
-		recordsWithMAddrs,
-		connError)
+// TraceLookup starts tracing the FIND_NODE requests that the host sends for the CID with the returned context,
+// returning as well the function that stops the trace and returns the steps of the lookup
+func (h *DHTHost) TraceLookup(ctx context.Context, cid *models.CidInfo) (context.Context, func() []*models.LookupStep) {
+	return h.lookupTracer.Trace(ctx, string(cid.CID.Hash()), pb.Message_FIND_NODE)
+}
+
+// TraceProviderLookup starts tracing the GET_PROVIDERS requests that the host sends for the CID with the returned
+// context, returning as well the function that stops the trace and returns the steps of the provider lookup
+func (h *DHTHost) TraceProviderLookup(ctx context.Context, cid *models.CidInfo) (context.Context, func() []*models.LookupStep) {
+	return h.lookupTracer.Trace(ctx, string(cid.CID.Hash()), pb.Message_GET_PROVIDERS)
 }
 
 func (h *DHTHost) GetClosestPeersToCid(ctx context.Context, cid *models.CidInfo) (time.Duration, []peer.ID, *kaddht.LookupMetrics, error) {
@@ -382,12 +531,52 @@ func (h *DHTHost) ProvideCid(ctx context.Context, cid *models.CidInfo) (time.Dur
 	log.WithFields(log.Fields{
 		"host-id": h.id,
 		"cid":     cid.CID.Hash().B58String(),
-	}).Debug("providing cid with", cid.ProvideOp)
+	}).Debugf("providing cid with %s", cid.ProvideOp)
 	startT := time.Now()
 	lookupMetrics, err := h.dht.DetailedProvide(ctx, cid.CID, true)
 	return time.Since(startT), lookupMetrics, err
 }
 
+// ProvideCidBatch provides all the given CIDs at once with the ProvideMany of the accelerated DHT client,
+// which sends the ADD_PROVIDER messages straight to the closest peers of its routing table (no lookups)
+func (h *DHTHost) ProvideCidBatch(ctx context.Context, cids []*models.CidInfo) (time.Duration, error) {
+	if h.fullRT == nil {
+		return 0, ErrorNoFullRT
+	}
+	keys := make([]mh.Multihash, 0, len(cids))
+	for _, c := range cids {
+		keys = append(keys, c.CID.Hash())
+	}
+	log.WithFields(log.Fields{
+		"host-id": h.id,
+		"cids":    len(keys),
+	}).Debugf("providing cid batch with fullRT=%t", h.fullRT != nil)
+	startT := time.Now()
+	err := h.fullRT.ProvideMany(ctx, keys)
+	return time.Since(startT), err
+}
+
+// WaitForFullRT blocks until the accelerated DHT client has crawled the network for the first time
+// (returns straight away if the host doesn't have one)
+func (h *DHTHost) WaitForFullRT(ctx context.Context) error {
+	if h.fullRT == nil || h.fullRT.Ready() {
+		return nil
+	}
+	log.WithField("host-id", h.id).Info("waiting for the accelerated DHT client to crawl the network")
+	ctx, cancel := context.WithTimeout(ctx, FullRTReadyTimeout)
+	defer cancel()
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for !h.fullRT.Ready() {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "accelerated DHT client not ready")
+		}
+	}
+	return nil
+}
+
 func (h *DHTHost) FindXXProvidersOfCID(
 	ctx context.Context,
 	cid *models.CidInfo,
@@ -415,10 +604,95 @@ func (h *DHTHost) FindProvidersOfCID(
 	return time.Since(startT), providers, err
 }
 
+// HasDelegatedRouting reports whether the host has a delegated routing endpoint to compare against the DHT
+func (h *DHTHost) HasDelegatedRouting() bool {
+	return h.delegatedRouter != nil
+}
+
+// ProvideCidDelegated provides the CID through the delegated routing endpoint of the host
+func (h *DHTHost) ProvideCidDelegated(ctx context.Context, cid *models.CidInfo) (time.Duration, error) {
+	if h.delegatedRouter == nil {
+		return 0, errors.New("no delegated routing endpoint for the host")
+	}
+	log.WithFields(log.Fields{
+		"host-id":  h.id,
+		"cid":      cid.CID.Hash().B58String(),
+		"endpoint": h.delegatedRouter.GetEndpoint(),
+	}).Debug("providing cid through delegated routing")
+	return h.delegatedRouter.Provide(ctx, cid.CID)
+}
+
+// FindProvidersDelegated looks for the providers of the CID through the delegated routing endpoint of the host
+func (h *DHTHost) FindProvidersDelegated(ctx context.Context, cid *models.CidInfo) (time.Duration, []peer.AddrInfo, error) {
+	if h.delegatedRouter == nil {
+		return 0, nil, errors.New("no delegated routing endpoint for the host")
+	}
+	log.WithFields(log.Fields{
+		"host-id":  h.id,
+		"cid":      cid.CID.Hash().B58String(),
+		"endpoint": h.delegatedRouter.GetEndpoint(),
+	}).Debug("looking for providers through delegated routing")
+	return h.delegatedRouter.FindProviders(ctx, cid.CID)
+}
+
+// FetchCidFromProvider retrieves the block of the CID over Bitswap after connecting the given provider,
+// returning the time to connect it and the time since the connection until the first block arrived,
+// and the peer that sent it. The Bitswap sessions ask any connected peer for the block, so the fetch fails
+// with ErrorFetchedFromOtherPeer if it wasn't sent by the provider. The block is not kept, so each fetch
+// goes to the network
+func (h *DHTHost) FetchCidFromProvider(
+	ctx context.Context,
+	provider peer.AddrInfo,
+	cid *models.CidInfo) (dialDuration, ttfb time.Duration, sender peer.ID, err error) {
+
+	log.WithFields(log.Fields{
+		"host-id":  h.id,
+		"cid":      cid.CID.Hash().B58String(),
+		"provider": provider.ID.String(),
+	}).Debug("fetching content")
+	if h.bitswap == nil {
+		return 0, 0, "", ErrorBitswapDisabled
+	}
+	startT := time.Now()
+	err = h.host.Connect(network.WithForceDirectDial(ctx, "prevent backoff"), provider)
+	dialDuration = time.Since(startT)
+	if err != nil {
+		return dialDuration, 0, "", err
+	}
+	stopTracking := h.fetchTracer.track(cid.CID)
+	fetchT := time.Now()
+	_, err = h.bitswap.NewSession(ctx).GetBlock(ctx, cid.CID)
+	ttfb = time.Since(fetchT)
+	sender = stopTracking()
+	if err == nil {
+		if dErr := h.blockstore.DeleteBlock(h.ctx, cid.CID); dErr != nil {
+			log.WithField("host-id", h.id).Warnf("unable to remove fetched block %s - %s", cid.CID, dErr.Error())
+		}
+		if sender != provider.ID {
+			err = ErrorFetchedFromOtherPeer
+		}
+	}
+	return dialDuration, ttfb, sender, err
+}
+
 func (h *DHTHost) Close() {
 	hlog := log.WithField("host-id", h.id)
 	var err error
 
+	if h.bitswap != nil {
+		err = h.bitswap.Close()
+		if err != nil {
+			hlog.Error(errors.Wrap(err, "unable to close bitswap exchange"))
+		}
+	}
+
+	if h.fullRT != nil {
+		err = h.fullRT.Close()
+		if err != nil {
+			hlog.Error(errors.Wrap(err, "unable to close accelerated DHT client"))
+		}
+	}
+
 	err = h.dht.Close()
 	if err != nil {
 		hlog.Error(errors.Wrap(err, "unable to close DHT client"))
@@ -42,6 +42,10 @@ const (
 	DialErrorHostIsDown                                 = "host_is_down"
 	DialErrorTooManyOpenFiles                           = "too_many_open_files"
 	DialErrorNegotiateSecurityProtocolNoTrailingNewLine = "negotiate_security_protocol_no_trailing_new_line"
+	// content fetch errors
+	FetchErrorNoProvider      = "no_provider"
+	FetchErrorBitswapDisabled = "bitswap_disabled"
+	FetchErrorOtherPeer       = "other_peer"
 )
 
 var KnownErrors = map[string]string{
@@ -67,6 +71,8 @@ var KnownErrors = map[string]string{
 	DialErrorStreamReset:                                "stream reset",
 	DialErrorTooManyOpenFiles:                           "too many open files",
 	DialErrorNegotiateSecurityProtocolNoTrailingNewLine: "failed to negotiate security protocol: message did not have trailing newline",
+	FetchErrorBitswapDisabled:                           "bitswap is not enabled",
+	FetchErrorOtherPeer:                                 "block sent by another peer",
 }
 
 func ParseConError(err error) string {
@@ -0,0 +1,52 @@
+package p2p
+
+import (
+	"sync"
+
+	bsmsg "github.com/ipfs/boxo/bitswap/message"
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// fetchTracer keeps track of the peer that sent each of the blocks fetched by the host over Bitswap, as the
+// Bitswap sessions ask all the connected peers (and the ones found through the DHT) for the blocks,
+// not only the provider that the content is being fetched from
+type fetchTracer struct {
+	m       sync.Mutex
+	senders map[cid.Cid]peer.ID // first peer that sent each of the tracked blocks (empty until it arrives)
+}
+
+func newFetchTracer() *fetchTracer {
+	return &fetchTracer{
+		senders: make(map[cid.Cid]peer.ID),
+	}
+}
+
+// track starts tracking the sender of the given block, returning the function that stops tracking
+// it and returns the peer that sent it (empty if it didn't arrive)
+func (t *fetchTracer) track(c cid.Cid) func() peer.ID {
+	t.m.Lock()
+	t.senders[c] = ""
+	t.m.Unlock()
+
+	return func() peer.ID {
+		t.m.Lock()
+		defer t.m.Unlock()
+		sender := t.senders[c]
+		delete(t.senders, c)
+		return sender
+	}
+}
+
+// MessageReceived records the sender of the tracked blocks that come in the message
+func (t *fetchTracer) MessageReceived(p peer.ID, msg bsmsg.BitSwapMessage) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	for _, block := range msg.Blocks() {
+		if sender, ok := t.senders[block.Cid()]; ok && sender == "" {
+			t.senders[block.Cid()] = p
+		}
+	}
+}
+
+func (t *fetchTracer) MessageSent(peer.ID, bsmsg.BitSwapMessage) {}
@@ -0,0 +1,31 @@
+package p2p
+
+import (
+	"testing"
+
+	bsmsg "github.com/ipfs/boxo/bitswap/message"
+	blocks "github.com/ipfs/go-block-format"
+)
+
+func TestFetchTracerFirstSender(t *testing.T) {
+	tracked := blocks.NewBlock([]byte("tracked"))
+	other := blocks.NewBlock([]byte("other"))
+	tracer := newFetchTracer()
+	stopTracking := tracer.track(tracked.Cid())
+
+	otherMsg := bsmsg.New(false)
+	otherMsg.AddBlock(other)
+	tracer.MessageReceived("a", otherMsg)
+
+	trackedMsg := bsmsg.New(false)
+	trackedMsg.AddBlock(tracked)
+	tracer.MessageReceived("b", trackedMsg)
+	tracer.MessageReceived("c", trackedMsg)
+
+	if sender := stopTracking(); sender != "b" {
+		t.Fatalf("expected the block to be sent by b, got %q", sender)
+	}
+	if len(tracer.senders) != 0 {
+		t.Fatal("the block should stop being tracked")
+	}
+}
@@ -116,6 +116,26 @@ func (p *HostPool) GetBestHost(newCid *models.CidInfo) (*DHTHost, error) {
 	return p.hostArray[0], nil
 }
 
+// GetHost returns the host of the pool with the given peer ID
+func (p *HostPool) GetHost(pid peer.ID) (*DHTHost, bool) {
+	p.m.RLock()
+	defer p.m.RUnlock()
+	h, ok := p.hostMap[pid]
+	return h, ok
+}
+
+// GetHosts returns all the hosts of the pool, sorted by their host ID
+func (p *HostPool) GetHosts() []*DHTHost {
+	p.m.RLock()
+	hosts := make([]*DHTHost, len(p.hostArray))
+	copy(hosts, p.hostArray)
+	p.m.RUnlock()
+	sort.Slice(hosts, func(i, j int) bool {
+		return hosts[i].GetHostID() < hosts[j].GetHostID()
+	})
+	return hosts
+}
+
 func (p *HostPool) GetHostWorkload() map[int]int {
 	summary := make(map[int]int)
 	p.m.RLock()
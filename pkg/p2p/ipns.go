@@ -0,0 +1,115 @@
+package p2p
+
+import (
+	"context"
+	"crypto/rand"
+	"time"
+
+	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ipfs/boxo/ipns"
+	ipnspb "github.com/ipfs/boxo/ipns/pb"
+	cid "github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// IpnsRecordTTL is the TTL of the published IPNS records (as the default one of the IPFS nodes)
+const IpnsRecordTTL = 1 * time.Hour
+
+// NewIpnsRecord creates an IPNS record pointing to the given CID, signed with a new key that
+// becomes the name of the record. The record is valid for the given validity since its creation
+func NewIpnsRecord(c cid.Cid, validity time.Duration, k int) (*models.IpnsRecord, error) {
+	sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to generate the IPNS key")
+	}
+	// the ed25519 public keys are inlined in the peer ID, no need to embed them in the record
+	name, err := peer.IDFromPrivateKey(sk)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to compose the IPNS name")
+	}
+	var seq uint64 = 0
+	eol := time.Now().Add(validity)
+	entry, err := ipns.Create(sk, []byte("/ipfs/"+c.String()), seq, eol, IpnsRecordTTL)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create the IPNS record")
+	}
+	value, err := entry.Marshal()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to marshal the IPNS record")
+	}
+	return models.NewIpnsRecord(c, name, ipns.RecordKey(name), value, seq, eol, k), nil
+}
+
+// PutIpnsRecord publishes the IPNS record through the DHT PutValue operation, which looks for the closest
+// peers to the key of the record and sends them the PUT_VALUE messages (notified through the msg notifier)
+func (h *DHTHost) PutIpnsRecord(ctx context.Context, record *models.IpnsRecord) (time.Duration, error) {
+	log.WithFields(log.Fields{
+		"host-id": h.id,
+		"cid":     record.Cid.Hash().B58String(),
+		"name":    record.Name.String(),
+	}).Debug("putting ipns record")
+	startT := time.Now()
+	err := h.dht.PutValue(ctx, record.Key, record.Value)
+	return time.Since(startT), err
+}
+
+// PingIpnsHolder asks the given holder for the IPNS record (GET_VALUE), checking the sequence
+// number and the validity of the record that it returns
+func (h *DHTHost) PingIpnsHolder(
+	ctx context.Context,
+	remotePeer peer.AddrInfo,
+	record *models.IpnsRecord) *models.IpnsPingResult {
+
+	hlog := log.WithFields(log.Fields{
+		"host-id":     h.id,
+		"cid":         record.Cid.Hash().B58String(),
+		"ipns-holder": remotePeer.ID.String(),
+	})
+	ctx = network.WithForceDirectDial(ctx, "prevent backoff")
+	pingRes := &models.IpnsPingResult{
+		Cid:      record.Cid,
+		PeerID:   remotePeer.ID,
+		Round:    -1, // caller will need to update the Round
+		PingTime: time.Now(),
+		Sequence: -1,
+	}
+
+	pingRes.ConError = h.connectPeer(ctx, hlog, remotePeer)
+	if pingRes.ConError == NoConnError {
+		pingRes.Active = true
+		rec, _, err := h.protoMessenger.GetValue(ctx, remotePeer.ID, record.Key)
+		switch {
+		case err != nil:
+			hlog.Debugf("unable to retrieve ipns record - error: %s", err.Error())
+		case rec != nil:
+			pingRes.HasRecord = true
+			pingRes.Sequence, pingRes.Valid = parseIpnsRecord(record.Name, rec.GetValue())
+		}
+		// close the connection to the peer
+		err = h.host.Network().ClosePeer(remotePeer.ID)
+		if err != nil {
+			hlog.Errorf("unable to close connection to peer %s - %s", remotePeer.ID.String(), err.Error())
+		}
+	}
+	pingRes.PingDuration = time.Since(pingRes.PingTime)
+	return pingRes
+}
+
+// parseIpnsRecord returns the sequence number of the given IPNS record (-1 if it can't be read),
+// and whether its signature is valid and it hasn't expired yet
+func parseIpnsRecord(name peer.ID, value []byte) (int64, bool) {
+	entry := new(ipnspb.IpnsEntry)
+	if err := entry.Unmarshal(value); err != nil {
+		return -1, false
+	}
+	pk, err := ipns.ExtractPublicKey(name, entry)
+	if err != nil {
+		return int64(entry.GetSequence()), false
+	}
+	return int64(entry.GetSequence()), ipns.Validate(pk, entry) == nil
+}
@@ -0,0 +1,84 @@
+package p2p
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cortze/ipfs-cid-hoarder/pkg/models"
+
+	pb "github.com/libp2p/go-libp2p-kad-dht/pb"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// LookupTracer gathers the FIND_NODE (or GET_PROVIDERS) requests sent by the DHT messenger for the lookups
+// that are being traced, so that the whole graph of a lookup can be tracked (not only its aggregates)
+type LookupTracer struct {
+	m sync.Mutex
+}
+
+// lookupTraceKey is the context key of the trace of the lookup that sends the request
+type lookupTraceKey struct{}
+
+type lookupTrace struct {
+	key     string
+	msgType pb.Message_MessageType
+	steps   []*models.LookupStep
+	done    bool
+}
+
+func NewLookupTracer() *LookupTracer {
+	return &LookupTracer{}
+}
+
+// Trace starts tracing the requests of the given type for the given key that are sent with the returned context,
+// so the requests that other operations send for the same key at the same time (i.e. the pings to the PR Holders)
+// are left out. It returns as well the function that stops the trace and returns the steps traced so far
+func (t *LookupTracer) Trace(
+	ctx context.Context,
+	key string,
+	msgType pb.Message_MessageType) (context.Context, func() []*models.LookupStep) {
+
+	trace := &lookupTrace{
+		key:     key,
+		msgType: msgType,
+		steps:   make([]*models.LookupStep, 0),
+	}
+	return context.WithValue(ctx, lookupTraceKey{}, trace), func() []*models.LookupStep {
+		t.m.Lock()
+		defer t.m.Unlock()
+		trace.done = true
+		return trace.steps
+	}
+}
+
+// record adds the request to the trace of the lookup that sent it, if it's still ongoing
+func (t *LookupTracer) record(ctx context.Context, not *MsgNotification) {
+	trace, ok := ctx.Value(lookupTraceKey{}).(*lookupTrace)
+	if !ok || trace.key != string(not.Msg.GetKey()) || trace.msgType != not.Msg.GetType() {
+		return
+	}
+	returnedPeers := make([]peer.ID, 0, len(not.Resp.CloserPeers))
+	for _, ai := range pb.PBPeersToPeerInfos(not.Resp.CloserPeers) {
+		returnedPeers = append(returnedPeers, ai.ID)
+	}
+	var returnedProviders []peer.AddrInfo
+	if not.Msg.GetType() == pb.Message_GET_PROVIDERS {
+		for _, ai := range pb.PBPeersToPeerInfos(not.Resp.ProviderPeers) {
+			returnedProviders = append(returnedProviders, *ai)
+		}
+	}
+
+	t.m.Lock()
+	defer t.m.Unlock()
+	if trace.done {
+		return
+	}
+	trace.steps = append(trace.steps, &models.LookupStep{
+		Peer:              not.RemotePeer,
+		QueryTime:         not.QueryTime,
+		QueryDuration:     not.QueryDuration,
+		ReturnedPeers:     returnedPeers,
+		ReturnedProviders: returnedProviders,
+		Error:             ParseConError(not.Error),
+	})
+}
@@ -0,0 +1,37 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/libp2p/go-libp2p-kad-dht/pb"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestLookupTracerScope(t *testing.T) {
+	tracer := NewLookupTracer()
+	key := "key"
+	getProviders := func(p peer.ID) *MsgNotification {
+		return &MsgNotification{
+			RemotePeer: p,
+			QueryTime:  time.Now(),
+			Msg:        *pb.NewMessage(pb.Message_GET_PROVIDERS, []byte(key), 0),
+		}
+	}
+	traceCtx, stopTrace := tracer.Trace(context.Background(), key, pb.Message_GET_PROVIDERS)
+
+	// only the requests of the lookup are traced, not the ones sent for the same key by other operations
+	tracer.record(traceCtx, getProviders("lookup"))
+	tracer.record(context.Background(), getProviders("pr-holder"))
+	steps := stopTrace()
+	if len(steps) != 1 || steps[0].Peer != "lookup" {
+		t.Fatalf("expected only the step of the lookup, got %d steps", len(steps))
+	}
+
+	// the requests that arrive once the trace is stopped are left out
+	tracer.record(traceCtx, getProviders("late"))
+	if steps = stopTrace(); len(steps) != 1 {
+		t.Fatalf("expected no steps after stopping the trace, got %d", len(steps))
+	}
+}
@@ -16,11 +16,13 @@ type MessageSender struct {
 	m             pb.MessageSender
 	blacklistedUA string
 	msgNot        *MsgNotifier
+	lookupTracer  *LookupTracer
 }
 
 func NewCustomMessageSender(blacklistedUA string, withMsgNot bool) *MessageSender {
 	msgSender := &MessageSender{
 		blacklistedUA: blacklistedUA,
+		lookupTracer:  NewLookupTracer(),
 	}
 	// only generate a notifier if requested
 	if withMsgNot {
@@ -38,8 +40,41 @@ func (ms *MessageSender) GetMsgNotifier() *MsgNotifier {
 	return ms.msgNot
 }
 
+func (ms *MessageSender) GetLookupTracer() *LookupTracer {
+	return ms.lookupTracer
+}
+
+// SendRequest is a custom wrapper on top of the pb.MessageSender that sends a given request to a peer,
+// tracing the FIND_NODE and GET_PROVIDERS requests (and their responses) of the lookups that are being traced, and
+// notifying the PUT_VALUE requests (if the notifier was enabled)
 func (ms *MessageSender) SendRequest(ctx context.Context, p peer.ID, pmes *pb.Message) (*pb.Message, error) {
-	return ms.m.SendRequest(ctx, p, pmes)
+	startT := time.Now()
+	resp, err := ms.m.SendRequest(ctx, p, pmes)
+	t := time.Since(startT)
+
+	if pmes.GetType() == pb.Message_PUT_VALUE && ms.msgNot != nil {
+		ms.msgNot.Notify(&MsgNotification{
+			RemotePeer:    p,
+			QueryTime:     startT,
+			QueryDuration: t,
+			Msg:           *pmes,
+			Error:         err,
+		})
+	}
+	if pmes.GetType() == pb.Message_FIND_NODE || pmes.GetType() == pb.Message_GET_PROVIDERS {
+		not := &MsgNotification{
+			RemotePeer:    p,
+			QueryTime:     startT,
+			QueryDuration: t,
+			Msg:           *pmes,
+			Error:         err,
+		}
+		if resp != nil {
+			not.Resp = *resp
+		}
+		ms.lookupTracer.record(ctx, not)
+	}
+	return resp, err
 }
 
 // SendMessage is a custom wrapper on top of the pb.MessageSender that sends a given msg to a peer and
@@ -0,0 +1,36 @@
+package p2p
+
+import (
+	"sync/atomic"
+
+	"github.com/libp2p/go-libp2p/core/control"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// onlineGater rejects every inbound and outbound connection while the host is offline,
+// so that the host neither serves its content nor answers to any other peer
+type onlineGater struct {
+	offline atomic.Bool
+}
+
+func (g *onlineGater) InterceptPeerDial(peer.ID) bool {
+	return !g.offline.Load()
+}
+
+func (g *onlineGater) InterceptAddrDial(peer.ID, ma.Multiaddr) bool {
+	return !g.offline.Load()
+}
+
+func (g *onlineGater) InterceptAccept(network.ConnMultiaddrs) bool {
+	return !g.offline.Load()
+}
+
+func (g *onlineGater) InterceptSecured(network.Direction, peer.ID, network.ConnMultiaddrs) bool {
+	return !g.offline.Load()
+}
+
+func (g *onlineGater) InterceptUpgraded(network.Conn) (bool, control.DisconnectReason) {
+	return !g.offline.Load(), 0
+}